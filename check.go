@@ -0,0 +1,162 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ColumnCheck is what CHECK constraints tell about the values of a column.
+// Only simple conditions joined by AND are understood, such as
+// "price >= 0", "char_length(name) <= 20" or "code ~ '^[A-Z]+$'".
+type ColumnCheck struct {
+	Min          string // lower bound of the value, empty if none
+	MinExclusive bool
+	Max          string // upper bound of the value, empty if none
+	MaxExclusive bool
+	MinLength    int // 0 if none
+	MaxLength    int // 0 if none
+	Patterns     []CheckPattern
+}
+
+// CheckPattern is a POSIX regular expression which the value must match.
+type CheckPattern struct {
+	Regexp          string
+	CaseInsensitive bool
+}
+
+const (
+	checkIdent = `\(?"?([A-Za-z_][\w$]*)"?\)?(?:::[a-z][a-z ]*)?`
+	checkNum   = `\(?'?(-?\d+(?:\.\d+)?)'?(?:::[a-z][a-z ]*)?\)?`
+	checkOp    = `\s*(>=|<=|>|<|=)\s*`
+)
+
+var (
+	regCheck        = regexp.MustCompile(`^CHECK \((.*)\)(?: NOT VALID)?$`)
+	regCheckAnd     = regexp.MustCompile(`\s+AND\s+`)
+	regCheckOr      = regexp.MustCompile(`\s+OR\s+`)
+	regCheckCompare = regexp.MustCompile(`^` + checkIdent + checkOp + checkNum + `$`)
+	regCheckReverse = regexp.MustCompile(`^` + checkNum + checkOp + checkIdent + `$`)
+	regCheckLength  = regexp.MustCompile(`^(?:char_length|character_length|length)\(` + checkIdent + `\)` + checkOp + checkNum + `$`)
+	regCheckPattern = regexp.MustCompile(`^` + checkIdent + `\s*(~\*?)\s*'((?:[^']|'')*)'(?:::[a-z][a-z ]*)?$`)
+)
+
+// reverseOp is the operator when both sides are swapped.
+var reverseOp = map[string]string{">=": "<=", "<=": ">=", ">": "<", "<": ">", "=": "="}
+
+// parseChecks parses the CHECK constraints of col. Conditions which are not
+// understood, or which do not refer to col, are ignored.
+func parseChecks(col Column) ColumnCheck {
+	var ret ColumnCheck
+	for _, def := range col.Checks {
+		m := regCheck.FindStringSubmatch(def)
+		if m == nil || regCheckOr.MatchString(m[1]) {
+			continue
+		}
+		for _, term := range regCheckAnd.Split(trimParens(m[1]), -1) {
+			ret.parseTerm(col.Name, trimParens(term))
+		}
+	}
+	return ret
+}
+
+func (c *ColumnCheck) parseTerm(name, term string) {
+	if m := regCheckCompare.FindStringSubmatch(term); m != nil && m[1] == name {
+		c.bound(m[2], m[3])
+		return
+	}
+	if m := regCheckReverse.FindStringSubmatch(term); m != nil && m[3] == name {
+		c.bound(reverseOp[m[2]], m[1])
+		return
+	}
+	if m := regCheckLength.FindStringSubmatch(term); m != nil && m[1] == name {
+		n, err := strconv.Atoi(m[3])
+		if err != nil {
+			return
+		}
+		switch m[2] {
+		case ">=":
+			c.minLength(n)
+		case ">":
+			c.minLength(n + 1)
+		case "<=":
+			c.maxLength(n)
+		case "<":
+			c.maxLength(n - 1)
+		case "=":
+			c.minLength(n)
+			c.maxLength(n)
+		}
+		return
+	}
+	if m := regCheckPattern.FindStringSubmatch(term); m != nil && m[1] == name {
+		c.Patterns = append(c.Patterns, CheckPattern{
+			Regexp:          strings.Replace(m[3], "''", "'", -1),
+			CaseInsensitive: m[2] == "~*",
+		})
+	}
+}
+
+// bound narrows the range of the value by "value op v".
+func (c *ColumnCheck) bound(op, v string) {
+	switch op {
+	case ">=", ">":
+		if c.Min == "" || compareNumber(v, c.Min) > 0 || (compareNumber(v, c.Min) == 0 && op == ">") {
+			c.Min, c.MinExclusive = v, op == ">"
+		}
+	case "<=", "<":
+		if c.Max == "" || compareNumber(v, c.Max) < 0 || (compareNumber(v, c.Max) == 0 && op == "<") {
+			c.Max, c.MaxExclusive = v, op == "<"
+		}
+	case "=":
+		c.bound(">=", v)
+		c.bound("<=", v)
+	}
+}
+
+func (c *ColumnCheck) minLength(n int) {
+	if n > c.MinLength {
+		c.MinLength = n
+	}
+}
+
+func (c *ColumnCheck) maxLength(n int) {
+	if c.MaxLength == 0 || n < c.MaxLength {
+		c.MaxLength = n
+	}
+}
+
+// compareNumber compares two numbers written in decimal.
+func compareNumber(a, b string) int {
+	x, _ := strconv.ParseFloat(a, 64)
+	y, _ := strconv.ParseFloat(b, 64)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// trimParens removes parentheses which enclose the whole of s.
+func trimParens(s string) string {
+	s = strings.TrimSpace(s)
+	for strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		depth := 0
+		for i, r := range s {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 && i < len(s)-1 {
+				// the first parenthesis is closed before the end
+				return s
+			}
+		}
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
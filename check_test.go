@@ -0,0 +1,32 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChecks(t *testing.T) {
+	cases := []struct {
+		checks   []string
+		expected ColumnCheck
+	}{
+		{[]string{"CHECK (x >= 0)"}, ColumnCheck{Min: "0"}},
+		{[]string{"CHECK (x > 0::numeric)"}, ColumnCheck{Min: "0", MinExclusive: true}},
+		{[]string{"CHECK (x >= '-1.5'::numeric AND x <= 100::numeric)"}, ColumnCheck{Min: "-1.5", Max: "100"}},
+		{[]string{"CHECK (((x >= 1) AND (x < 10)))"}, ColumnCheck{Min: "1", Max: "10", MaxExclusive: true}},
+		{[]string{"CHECK (0 < x)", "CHECK (x >= 5)"}, ColumnCheck{Min: "5"}},
+		{[]string{"CHECK (char_length(x) <= 20)"}, ColumnCheck{MaxLength: 20}},
+		{[]string{"CHECK (char_length((x)::text) > 2)"}, ColumnCheck{MinLength: 3}},
+		{[]string{"CHECK (x ~ '^[A-Z]+$'::text)"}, ColumnCheck{Patterns: []CheckPattern{{Regexp: "^[A-Z]+$"}}}},
+		{[]string{"CHECK (x ~* 'it''s'::text)"}, ColumnCheck{Patterns: []CheckPattern{{Regexp: "it's", CaseInsensitive: true}}}},
+		{[]string{`CHECK ("x" <> ''::text)`}, ColumnCheck{}},
+		{[]string{"CHECK (y >= 0)"}, ColumnCheck{}},
+		{[]string{"CHECK (x >= 0 OR x IS NULL)"}, ColumnCheck{}},
+	}
+	for _, c := range cases {
+		actual := parseChecks(Column{Name: "x", Checks: c.checks})
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%v: expected %+v, actual: %+v", c.checks, c.expected, actual)
+		}
+	}
+}
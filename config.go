@@ -13,6 +13,7 @@ import (
 
 type Config struct {
 	Src        string            `json:"src"`
+	Schemas    []string          `json:"schemas"`
 	GenConfigs []json.RawMessage `json:"generators"`
 	generators []Generator
 	db         *sql.DB
@@ -70,10 +71,25 @@ func NewGenerator(db *sql.DB, root string, config json.RawMessage) (Generator, e
 		return NewProtoBuf(db, root, config)
 	case SphinxTypeName:
 		return NewSphinx(db, root, config)
+	case GoTypeName:
+		return NewGo(db, root, config)
+	case TypeScriptTypeName:
+		return NewTypeScript(db, root, config)
+	case JSONSchemaTypeName:
+		return NewJSONSchema(db, root, config)
+	case OpenAPITypeName:
+		return NewOpenAPI(db, root, config)
+	case GraphQLTypeName:
+		return NewGraphQL(db, root, config)
+	case ERDTypeName:
+		return NewERD(db, root, config)
+	case MarkdownTypeName:
+		return NewMarkdown(db, root, config)
+	case KotlinTypeName:
+		return NewKotlin(db, root, config)
 	default:
 		return nil, fmt.Errorf("unknown generator: %s", c.Generator)
 	}
-	return nil, nil
 }
 
 func (c *Config) connect() (*sql.DB, error) {
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"regexp"
 )
 
 type Generator interface {
@@ -27,6 +29,219 @@ func DirExists(dir string) error {
 	return nil
 }
 
+// DefaultSchema is the schema whose files are written directly into the
+// output directory. Files of other schemas go into a sub directory named
+// after the schema.
+const DefaultSchema = "public"
+
+func schemaDir(schema string) string {
+	if schema == "" || schema == DefaultSchema {
+		return ""
+	}
+	return schema
+}
+
+// qualifiedName returns name prefixed with its schema unless the schema is
+// the default one.
+func qualifiedName(schema, name string) string {
+	if schemaDir(schema) == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// schemaPackage returns the dotted package name for schema. The default
+// schema uses base as is, others get the schema name appended.
+func schemaPackage(base, schema string) string {
+	dir := schemaDir(schema)
+	if dir == "" || base == "" {
+		return base
+	}
+	return base + "." + dir
+}
+
+// typesBySchema groups types by schema. Schemas are returned in order of
+// appearance.
+func typesBySchema(types []Type) ([]string, map[string][]Type) {
+	var schemas []string
+	ret := make(map[string][]Type)
+	for _, typ := range types {
+		if _, ok := ret[typ.Schema]; !ok {
+			schemas = append(schemas, typ.Schema)
+		}
+		ret[typ.Schema] = append(ret[typ.Schema], typ)
+	}
+	return schemas, ret
+}
+
+// checkNames fails if name gives the same name to tables or types of
+// different schemas, for generators which write all schemas into one
+// directory or package. Otherwise one of them would silently overwrite
+// the other.
+func checkNames(ins InspectResult, ignoreTables []string, name func(string) string) error {
+	names := make(map[string]string)
+	add := func(kind, schema, n string) error {
+		key := kind + " " + qualifiedName(schema, n)
+		if prev, ok := names[name(n)]; ok {
+			return fmt.Errorf("%s and %s are both named %s, exclude one of them by schemas or ignore_tables", prev, key, name(n))
+		}
+		names[name(n)] = key
+		return nil
+	}
+	for _, table := range ins.Tables {
+		if partContainsRegex(ignoreTables, table.Name) {
+			continue
+		}
+		if err := add("table", table.Schema, table.Name); err != nil {
+			return err
+		}
+	}
+	for _, typ := range ins.Types {
+		if err := add("type", typ.Schema, typ.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createFile creates name under dir, making the schema sub directory if
+// needed.
+func createFile(dir, name string) (*os.File, error) {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// foreignKeyNames returns snake case names of the object valued fields
+// which replace foreign keys, keyed by the foreign key name. A single join
+// column "customer_id" becomes "customer", otherwise the referenced table
+// name is used. Names colliding with a column fall back to the constraint
+// name.
+func foreignKeyNames(table Table, fks []ForeignKey) map[string]string {
+	ret := make(map[string]string)
+	var used []string
+	for _, col := range table.Columns {
+		used = append(used, col.Name)
+	}
+	for _, fk := range fks {
+		name := fk.RefTable
+		if len(fk.Columns) == 1 && strings.HasSuffix(fk.Columns[0], "_id") && fk.Columns[0] != "_id" {
+			name = strings.TrimSuffix(fk.Columns[0], "_id")
+		}
+		if contains(used, name) {
+			name = fk.Name
+		}
+		used = append(used, name)
+		ret[fk.Name] = name
+	}
+	return ret
+}
+
+// pluralize returns a naive English plural of name. Names ending in "s"
+// are taken as plural already, such as table names like "orders", except
+// for "ss" and "us" as in "address" and "status".
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"):
+		return name + "es"
+	case strings.HasSuffix(name, "s"):
+		return name
+	case strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsAny(name[len(name)-2:len(name)-1], "aeiou"):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+// TypeOverride replaces the type of columns in generated code. Imports and
+// annotations are written in the form of the target language. In config, it
+// can also be written as a string which is the type only.
+type TypeOverride struct {
+	Type        string   `json:"type"`
+	Imports     []string `json:"imports"`
+	Annotations []string `json:"annotations"`
+}
+
+func (o *TypeOverride) UnmarshalJSON(b []byte) error {
+	var typ string
+	if err := json.Unmarshal(b, &typ); err == nil {
+		o.Type = typ
+		return nil
+	}
+	type typeOverride TypeOverride
+	return json.Unmarshal(b, (*typeOverride)(o))
+}
+
+// TypeOverrides is the type_overrides of generator configs. Keys are
+// "table.column", "schema.table.column", a Postgres type such as "citext",
+// a glob pattern of types such as "numeric(*)", or a regexp enclosed with
+// "/" such as "/^character varying/". Types are matched against the type
+// name of the column as is, so arrays are "inet[]".
+type TypeOverrides map[string]TypeOverride
+
+// validate checks that patterns of the keys can be compiled.
+func (o TypeOverrides) validate() error {
+	for key := range o {
+		if _, err := typeOverridePattern(key); err != nil {
+			return fmt.Errorf("invalid type_overrides key %s: %s", key, err)
+		}
+	}
+	return nil
+}
+
+// find returns the override of col in table. Column keys are preferred
+// over an exact type, and patterns are tried last in order of the keys.
+func (o TypeOverrides) find(table Table, col Column) (TypeOverride, bool) {
+	if len(o) == 0 {
+		return TypeOverride{}, false
+	}
+	for _, key := range []string{
+		table.Schema + "." + table.Name + "." + col.Name,
+		table.Name + "." + col.Name,
+		col.DataType,
+	} {
+		if ret, ok := o[key]; ok {
+			return ret, true
+		}
+	}
+
+	var keys []string
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		r, err := typeOverridePattern(key)
+		if err != nil || r == nil {
+			continue
+		}
+		if r.MatchString(col.DataType) {
+			return o[key], true
+		}
+	}
+	return TypeOverride{}, false
+}
+
+// typeOverridePattern returns the regexp of key, or nil if key is not a
+// pattern.
+func typeOverridePattern(key string) (*regexp.Regexp, error) {
+	if len(key) > 1 && strings.HasPrefix(key, "/") && strings.HasSuffix(key, "/") {
+		return regexp.Compile(key[1 : len(key)-1])
+	}
+	if strings.Contains(key, "*") {
+		var ps []string
+		for _, p := range strings.Split(key, "*") {
+			ps = append(ps, regexp.QuoteMeta(p))
+		}
+		return regexp.Compile("^" + strings.Join(ps, ".*") + "$")
+	}
+	return nil, nil
+}
+
 func SnakeToUpper(src string) string {
 	var ret []string
 	for _, b := range strings.Split(src, "_") {
@@ -55,6 +270,17 @@ func SnakeToLowerCamel(src string) string {
 	return strings.Join(ret, "")
 }
 
+// enumConstant returns the Java and Kotlin enum constant name of label, in
+// upper snake case with "_" for runes which can not be used in identifiers.
+// Labels which start with a digit get "VALUE_".
+func enumConstant(label string) string {
+	name := protoBufIdent(label)
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = strings.TrimSuffix("VALUE_"+name, "_")
+	}
+	return name
+}
+
 func isNumber(v string) bool {
 	if _, err := strconv.Atoi(v); err == nil {
 		return true
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+type ERDConfig struct {
+	Output       string   `json:"output"`
+	Templates    string   `json:"templates"`
+	FileName     string   `json:"file_name"`
+	IgnoreTables []string `json:"ignore_tables"`
+	// TargetTables and Schemas limit the diagram to matched tables and
+	// schemas (regexp). Empty means all.
+	TargetTables []string `json:"target_tables"`
+	Schemas      []string `json:"schemas"`
+	// Formats are "mermaid" and/or "dot". Default is mermaid only.
+	Formats []string `json:"formats"`
+	// ClusterBySchema groups tables by schema (dot only).
+	ClusterBySchema bool `json:"cluster_by_schema"`
+}
+
+type ERD struct {
+	db       *sql.DB
+	config   ERDConfig
+	ins      InspectResult
+	template *template.Template
+	root     string
+}
+
+type ERDEntity struct {
+	ID      string
+	Label   string
+	Schema  string
+	Columns []ERDColumn
+}
+
+type ERDColumn struct {
+	Name        string
+	Type        string
+	MermaidName string
+	MermaidType string
+	Keys        string
+	Comment     string
+}
+
+type ERDRelation struct {
+	From        string
+	FromLabel   string
+	FromColumns []string
+	To          string
+	ToLabel     string
+	ToColumns   []string
+	Label       string
+	Cardinality string
+	Optional    bool
+}
+
+type ERDCluster struct {
+	Name     string
+	Entities []ERDEntity
+}
+
+const ERDTypeName = "erd"
+
+const (
+	ERDFormatMermaid = "mermaid"
+	ERDFormatDot     = "dot"
+)
+
+var erdExtensions = map[string]string{
+	ERDFormatMermaid: ".mmd",
+	ERDFormatDot:     ".dot",
+}
+
+func NewERD(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadERDConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := ERD{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *ERD) GetType() string {
+	return ERDTypeName
+}
+
+func (gen *ERD) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	log.Printf("templates: %s", filePathJoinRoot(gen.root, gen.config.Templates))
+	gen.ins = ins
+
+	// Load templates
+	tdir := filepath.Join(filePathJoinRoot(gen.root, gen.config.Templates), "*.tmpl")
+	t := template.Must(template.New("").Funcs(erdFuncs).ParseGlob(tdir))
+	gen.template = t
+
+	for _, format := range gen.config.Formats {
+		fileName := gen.config.FileName + erdExtensions[format]
+		file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), fileName))
+		if err != nil {
+			return errors.Wrap(err, "build create file")
+		}
+		if err := gen.buildDiagram(file, format); err != nil {
+			file.Close()
+			return errors.Wrap(err, "build write "+format)
+		}
+		file.Close()
+	}
+	return nil
+}
+
+func (gen *ERD) buildDiagram(wr io.Writer, format string) error {
+	var tables []Table
+	for _, table := range gen.ins.Tables {
+		if gen.target(table) {
+			tables = append(tables, table)
+		}
+	}
+
+	var entities []ERDEntity
+	for _, table := range tables {
+		entities = append(entities, gen.entity(table))
+	}
+
+	return gen.template.ExecuteTemplate(wr, format, map[string]interface{}{
+		"entities":  entities,
+		"clusters":  gen.clusters(entities),
+		"relations": gen.relations(tables),
+	})
+}
+
+// target reports whether table is drawn.
+func (gen *ERD) target(table Table) bool {
+	if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+		return false
+	}
+	if len(gen.config.TargetTables) > 0 && !partContainsRegex(gen.config.TargetTables, table.Name) {
+		return false
+	}
+	if len(gen.config.Schemas) > 0 && !partContainsRegex(gen.config.Schemas, table.Schema) {
+		return false
+	}
+	return true
+}
+
+var regMermaidInvalid = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]+`)
+
+// erdFuncs escape names and labels which are written in quotes.
+var erdFuncs = template.FuncMap{
+	"dot":     dotString,
+	"mermaid": mermaidString,
+}
+
+// dotString escapes s in a double quoted DOT ID.
+func dotString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// mermaidString escapes s in a double quoted mermaid string, which has no
+// escape sequence.
+func mermaidString(s string) string {
+	return strings.Replace(strings.Replace(s, "\n", " ", -1), `"`, `'`, -1)
+}
+
+func (gen *ERD) entityID(schema, name string) string {
+	return regMermaidInvalid.ReplaceAllString(strings.Replace(qualifiedName(schema, name), ".", "__", -1), "_")
+}
+
+func (gen *ERD) entity(table Table) ERDEntity {
+	ret := ERDEntity{
+		ID:     gen.entityID(table.Schema, table.Name),
+		Label:  qualifiedName(table.Schema, table.Name),
+		Schema: table.Schema,
+	}
+	for _, col := range table.Columns {
+		var keys []string
+		if col.PrimaryKey {
+			keys = append(keys, "PK")
+		}
+		if _, ok := table.FindForeignKey(col.Name); ok {
+			keys = append(keys, "FK")
+		}
+		if !col.PrimaryKey && uniqueColumns(table, []string{col.Name}) {
+			keys = append(keys, "UK")
+		}
+		ret.Columns = append(ret.Columns, ERDColumn{
+			Name:        col.Name,
+			Type:        col.DataType,
+			MermaidName: regMermaidInvalid.ReplaceAllString(col.Name, "_"),
+			MermaidType: regMermaidInvalid.ReplaceAllString(col.DataType, "_"),
+			Keys:        strings.Join(keys, ", "),
+			Comment:     mermaidString(col.Comment.String),
+		})
+	}
+	return ret
+}
+
+// uniqueColumns reports whether columns are unique in table by a primary
+// key or an unique index on exactly those columns.
+func uniqueColumns(table Table, columns []string) bool {
+	var pks []string
+	for _, col := range table.PrimaryKeys {
+		pks = append(pks, col.Name)
+	}
+	if sameColumns(pks, columns) {
+		return true
+	}
+	for _, index := range table.Indexs {
+		var names []string
+		for _, col := range index.Columns {
+			names = append(names, col.Name)
+		}
+		if sameColumns(names, columns) {
+			return true
+		}
+	}
+	for _, col := range table.Columns {
+		if col.Unique && len(columns) == 1 && col.Name == columns[0] {
+			return true
+		}
+	}
+	return false
+}
+
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) || len(a) == 0 {
+		return false
+	}
+	x := append([]string{}, a...)
+	y := append([]string{}, b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (gen *ERD) relations(tables []Table) []ERDRelation {
+	var ret []ERDRelation
+	for _, table := range tables {
+		for _, fk := range table.ForeignKeys {
+			ref, err := gen.ins.FindTable(fk.RefSchema, fk.RefTable)
+			if err != nil || !gen.target(ref) {
+				continue
+			}
+			optional := false
+			for _, col := range table.Columns {
+				if contains(fk.Columns, col.Name) && !col.NotNull {
+					optional = true
+				}
+			}
+			// parent side: exactly one or zero or one
+			left := "||"
+			if optional {
+				left = "|o"
+			}
+			// child side: zero or more, or zero or one if unique
+			right := "o{"
+			if uniqueColumns(table, fk.Columns) {
+				right = "o|"
+			}
+			ret = append(ret, ERDRelation{
+				From:        gen.entityID(table.Schema, table.Name),
+				FromLabel:   qualifiedName(table.Schema, table.Name),
+				FromColumns: fk.Columns,
+				To:          gen.entityID(fk.RefSchema, fk.RefTable),
+				ToLabel:     qualifiedName(fk.RefSchema, fk.RefTable),
+				ToColumns:   fk.RefColumns,
+				Label:       fk.Name,
+				Cardinality: left + "--" + right,
+				Optional:    optional,
+			})
+		}
+	}
+	return ret
+}
+
+// clusters groups entities by schema. Without cluster_by_schema, all
+// entities are in a single unnamed cluster.
+func (gen *ERD) clusters(entities []ERDEntity) []ERDCluster {
+	if !gen.config.ClusterBySchema {
+		return []ERDCluster{{Entities: entities}}
+	}
+	var ret []ERDCluster
+	for _, e := range entities {
+		found := false
+		for i := range ret {
+			if ret[i].Name == e.Schema {
+				ret[i].Entities = append(ret[i].Entities, e)
+				found = true
+			}
+		}
+		if !found {
+			ret = append(ret, ERDCluster{Name: e.Schema, Entities: []ERDEntity{e}})
+		}
+	}
+	return ret
+}
+
+func loadERDConfig(root string, raw json.RawMessage) (ERDConfig, error) {
+	var ec ERDConfig
+	if err := json.Unmarshal(raw, &ec); err != nil {
+		return ec, fmt.Errorf("erd config error: %s", err)
+	}
+	output := filePathJoinRoot(root, ec.Output)
+	if err := DirExists(output); err != nil {
+		return ec, fmt.Errorf("erd output is not exists: %s", ec.Output)
+	}
+	if ec.FileName == "" {
+		ec.FileName = "erd"
+	}
+	if len(ec.Formats) == 0 {
+		ec.Formats = []string{ERDFormatMermaid}
+	}
+	for _, f := range ec.Formats {
+		if _, ok := erdExtensions[f]; !ok {
+			return ec, fmt.Errorf("erd unknown format: %s", f)
+		}
+	}
+	return ec, nil
+}
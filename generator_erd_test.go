@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func newTestERD(config ERDConfig) ERD {
+	ins := relationInspectResult()
+	ins.Tables[1].Columns = append(ins.Tables[1].Columns, Column{Name: "placed_at", DataType: "timestamp with time zone"})
+	ins.Tables = append(ins.Tables, Table{
+		Schema:  "audit",
+		Name:    "log",
+		Columns: []Column{{Name: "id", DataType: "bigint", PrimaryKey: true}},
+	})
+	return ERD{
+		config:   config,
+		ins:      ins,
+		template: template.Must(template.New("").Funcs(erdFuncs).ParseGlob("templates/erd/*.tmpl")),
+	}
+}
+
+func TestERDMermaid(t *testing.T) {
+	gen := newTestERD(ERDConfig{})
+	var buf bytes.Buffer
+	if err := gen.buildDiagram(&buf, ERDFormatMermaid); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"erDiagram\n    customer {\n        bigint id PK\n        text name\n    }",
+		"        bigint customer_id FK\n",
+		"        timestamp_with_time_zone placed_at\n",
+		"    audit__log {\n",
+		`    customer ||--o{ order_item : "order_item_customer_id_fkey"`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	gen.config.Schemas = []string{"^audit$"}
+	buf.Reset()
+	if err := gen.buildDiagram(&buf, ERDFormatMermaid); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); strings.Contains(out, "customer") || !strings.Contains(out, "audit__log") {
+		t.Errorf("unexpected filtered diagram\n%s", out)
+	}
+}
+
+func TestERDDot(t *testing.T) {
+	gen := newTestERD(ERDConfig{ClusterBySchema: true})
+	var buf bytes.Buffer
+	if err := gen.buildDiagram(&buf, ERDFormatDot); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"subgraph \"cluster_public\" {\n    label=\"public\";",
+		"subgraph \"cluster_audit\" {",
+		`<tr><td port="customer_id" align="left">customer_id</td><td align="left">bigint</td><td>FK</td></tr>`,
+		`"order_item":"customer_id" -> "customer":"id" [label="order_item_customer_id_fkey"];`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestERDEscape(t *testing.T) {
+	gen := newTestERD(ERDConfig{})
+	gen.ins.Tables[0].Name = `my "customer"`
+	gen.ins.Tables[1].Columns[1].Name = `customer id`
+	gen.ins.Tables[1].ForeignKeys[0].RefTable = `my "customer"`
+	gen.ins.Tables[1].ForeignKeys[0].Columns = []string{`customer id`}
+	gen.ins.Tables[1].ForeignKeys[0].Name = `fk "customer"`
+
+	var buf bytes.Buffer
+	if err := gen.buildDiagram(&buf, ERDFormatMermaid); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"    my_customer_ {\n",
+		"        bigint customer_id FK\n",
+		`    my_customer_ ||--o{ order_item : "fk 'customer'"`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	if err := gen.buildDiagram(&buf, ERDFormatDot); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, expected := range []string{
+		`  "my \"customer\"" [label=<`,
+		`<td port="customer id" align="left">customer id</td>`,
+		`  "order_item":"customer id" -> "my \"customer\"":"id" [label="fk \"customer\""];`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
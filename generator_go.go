@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type GoConfig struct {
+	Output       string   `json:"output"`
+	Templates    string   `json:"templates"`
+	PackageName  string   `json:"package_name"`
+	IgnoreTables []string `json:"ignore_tables"`
+	// NullStyle is the type of nullable columns, "sql" (sql.Null*, default)
+	// or "pointer".
+	NullStyle string `json:"null_style"`
+
+	TypeOverrides TypeOverrides `json:"type_overrides"`
+}
+
+type Go struct {
+	db       *sql.DB
+	config   GoConfig
+	ins      InspectResult
+	template *template.Template
+	root     string
+}
+
+type GoMember struct {
+	Name    string
+	Type    string
+	Column  string
+	Comment string
+	Tags    string   // extra struct tags of type_overrides
+	Imports []string // imports of type_overrides
+}
+
+type GoTypeMember struct {
+	Name    string
+	Comment string
+	Type    Type
+	Values  []GoEnumValue
+}
+
+type GoEnumValue struct {
+	Name  string
+	Value string
+}
+
+const GoTypeName = "go"
+
+const (
+	GoNullStyleSQL     = "sql"
+	GoNullStylePointer = "pointer"
+)
+
+func NewGo(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadGoConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := Go{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *Go) GetType() string {
+	return GoTypeName
+}
+
+func (gen *Go) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	log.Printf("templates: %s", filePathJoinRoot(gen.root, gen.config.Templates))
+	gen.ins = ins
+
+	// Load templates
+	tdir := filepath.Join(filePathJoinRoot(gen.root, gen.config.Templates), "*.tmpl")
+	t := template.Must(template.ParseGlob(tdir))
+	gen.template = t
+	if err := checkNames(gen.ins, gen.config.IgnoreTables, goName); err != nil {
+		return errors.Wrap(err, "build")
+	}
+
+	// Build tables
+	for _, table := range gen.ins.Tables {
+		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+			continue
+		}
+		// the suffix keeps names such as "foo_test" or "foo_windows" from
+		// becoming test files or build constraints, and "enum" from enum.go
+		fileName := strings.ToLower(table.Name) + "_table.go"
+		if err := gen.writeFile(fileName, func(wr io.Writer) error {
+			return gen.buildTable(wr, table)
+		}); err != nil {
+			return errors.Wrap(err, "build write table")
+		}
+	}
+
+	// Build types
+	if len(gen.ins.Types) > 0 {
+		if err := gen.writeFile("enum.go", func(wr io.Writer) error {
+			return gen.buildType(wr, gen.ins.Types)
+		}); err != nil {
+			return errors.Wrap(err, "build write type")
+		}
+	}
+
+	return nil
+}
+
+// writeFile renders a file by build and writes it gofmt-ed.
+func (gen *Go) writeFile(fileName string, build func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := build(&buf); err != nil {
+		return err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "gofmt "+fileName)
+	}
+	file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), fileName))
+	if err != nil {
+		return errors.Wrap(err, "build create file")
+	}
+	defer file.Close()
+	_, err = file.Write(src)
+	return err
+}
+
+func (gen *Go) buildTable(wr io.Writer, table Table) error {
+	members := gen.members(table)
+	return gen.template.ExecuteTemplate(wr, "struct", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"comment":      strings.Replace(table.Comment.String, "\n", " ", -1),
+		"table":        table,
+		"name":         goName(table.Name),
+		"member":       members,
+		"imports":      gen.imports(members),
+	})
+}
+
+func (gen *Go) members(table Table) []GoMember {
+	var ret []GoMember
+
+	for _, col := range table.Columns {
+		m := GoMember{
+			Name:    goName(col.Name),
+			Column:  col.Name,
+			Comment: strings.Replace(col.Comment.String, "\n", " ", -1),
+		}
+		if o, ok := gen.config.TypeOverrides.find(table, col); ok {
+			m.Type = o.Type
+			m.Tags = strings.Join(o.Annotations, " ")
+			m.Imports = o.Imports
+		} else {
+			m.Type = gen.convertType(col)
+		}
+		ret = append(ret, m)
+	}
+	return ret
+}
+
+// imports returns the packages used by the member types.
+func (gen *Go) imports(members []GoMember) []string {
+	var ret []string
+	for _, m := range members {
+		var pkg string
+		switch {
+		case strings.Contains(m.Type, "sql."):
+			pkg = "database/sql"
+		case strings.Contains(m.Type, "time."):
+			pkg = "time"
+		case strings.Contains(m.Type, "json."):
+			pkg = "encoding/json"
+		case strings.Contains(m.Type, "pq."):
+			pkg = "github.com/lib/pq"
+		}
+		if pkg != "" && !contains(ret, pkg) {
+			ret = append(ret, pkg)
+		}
+		for _, pkg := range m.Imports {
+			if !contains(ret, pkg) {
+				ret = append(ret, pkg)
+			}
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (gen *Go) buildType(wr io.Writer, types []Type) error {
+	var members []GoTypeMember
+	for _, typ := range types {
+		name := goName(typ.Name)
+		var vs []GoEnumValue
+		labels := make(map[string]string)
+		for _, val := range typ.Values {
+			v := GoEnumValue{
+				Name:  name + goName(val),
+				Value: val,
+			}
+			if isNumber(val) {
+				v.Name = name + "Value" + goName(strings.Replace(val, ".", "_", -1))
+			}
+			if prev, ok := labels[v.Name]; ok {
+				return fmt.Errorf("labels %q and %q of %s are both named %s", prev, val, typ.Name, v.Name)
+			}
+			labels[v.Name] = val
+			vs = append(vs, v)
+		}
+		m := GoTypeMember{
+			Name:    name,
+			Comment: strings.Replace(typ.Comment.String, "\n", " ", -1),
+			Type:    typ,
+			Values:  vs,
+		}
+		members = append(members, m)
+	}
+
+	return gen.template.ExecuteTemplate(wr, "enum", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"members":      members,
+	})
+}
+
+// convertType returns the Go type of col. Nullable columns are wrapped by
+// sql.Null* or a pointer depending on null_style.
+func (gen *Go) convertType(col Column) string {
+	t := strings.Replace(col.DataType, "[]", "", 1)
+	typ := gen.baseType(t)
+	if col.Array {
+		if gen.config.NullStyle == GoNullStyleSQL {
+			return goArrayType(typ)
+		}
+		return "[]" + typ
+	}
+	if col.NotNull {
+		return typ
+	}
+	switch typ {
+	case "[]byte", "json.RawMessage", "interface{}":
+		// nil is the null value
+		return typ
+	}
+	if gen.config.NullStyle == GoNullStylePointer {
+		return "*" + typ
+	}
+	switch typ {
+	case "string":
+		return "sql.NullString"
+	case "int16":
+		return "sql.NullInt16"
+	case "int32":
+		return "sql.NullInt32"
+	case "int64":
+		return "sql.NullInt64"
+	case "float32", "float64":
+		return "sql.NullFloat64"
+	case "bool":
+		return "sql.NullBool"
+	case "time.Time":
+		return "sql.NullTime"
+	}
+	// enum
+	return "*" + typ
+}
+
+// goArrayType returns the lib/pq array type of the element type typ, as
+// database/sql can not scan arrays into slices. Arrays of other types are
+// read as their text representation.
+func goArrayType(typ string) string {
+	switch typ {
+	case "bool":
+		return "pq.BoolArray"
+	case "int16", "int32":
+		return "pq.Int32Array"
+	case "int64":
+		return "pq.Int64Array"
+	case "float32":
+		return "pq.Float32Array"
+	case "float64":
+		return "pq.Float64Array"
+	case "[]byte":
+		return "pq.ByteaArray"
+	}
+	return "pq.StringArray"
+}
+
+// goTypes maps Postgres types to Go types. Types which have no counterpart
+// are string, the text representation of Postgres.
+var goTypes = map[PgType]string{
+	PgBool:        "bool",
+	PgInt16:       "int16",
+	PgInt32:       "int32",
+	PgInt64:       "int64",
+	PgFloat32:     "float32",
+	PgFloat64:     "float64",
+	PgNumeric:     "string", // string keeps the precision
+	PgMoney:       "string",
+	PgText:        "string",
+	PgUUID:        "string",
+	PgBytes:       "[]byte",
+	PgDate:        "time.Time",
+	PgTime:        "string",
+	PgTimeTZ:      "string",
+	PgTimestamp:   "time.Time",
+	PgTimestampTZ: "time.Time",
+	PgInterval:    "string",
+	PgJSON:        "json.RawMessage",
+	PgXML:         "string",
+	PgNetwork:     "string",
+	PgMacAddr:     "string",
+	PgBit:         "string",
+	PgTextSearch:  "string",
+	PgGeometric:   "string",
+	PgRange:       "string",
+}
+
+func (gen *Go) baseType(t string) string {
+	if pt, ok := lookupPgType(t); ok {
+		return goTypes[pt]
+	}
+	typ, err := gen.ins.FindType(t)
+	if err == nil {
+		return goName(typ.Name)
+	}
+	warnUnknownType(GoTypeName, t, "interface{}")
+	return "interface{}"
+}
+
+// goInitialisms are upper cased as golint suggests.
+var goInitialisms = []string{"ID", "URL", "URI", "UUID", "JSON", "HTTP", "HTTPS", "API", "SQL", "IP", "HTML", "XML"}
+
+// goName converts snake case to an exported Go identifier.
+func goName(src string) string {
+	src = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, src)
+	var ret []string
+	for _, b := range strings.Split(src, "_") {
+		if contains(goInitialisms, strings.ToUpper(b)) {
+			ret = append(ret, strings.ToUpper(b))
+		} else {
+			ret = append(ret, strings.Title(b))
+		}
+	}
+	name := strings.Join(ret, "")
+	if name != "" && !(name[0] >= 'A' && name[0] <= 'Z') {
+		name = "X" + name
+	}
+	return name
+}
+
+func loadGoConfig(root string, raw json.RawMessage) (GoConfig, error) {
+	var gc GoConfig
+	if err := json.Unmarshal(raw, &gc); err != nil {
+		return gc, fmt.Errorf("go config error: %s", err)
+	}
+	output := filePathJoinRoot(root, gc.Output)
+	if err := DirExists(output); err != nil {
+		return gc, fmt.Errorf("go output is not exists: %s", gc.Output)
+	}
+	switch gc.NullStyle {
+	case "":
+		gc.NullStyle = GoNullStyleSQL
+	case GoNullStyleSQL, GoNullStylePointer:
+	default:
+		return gc, fmt.Errorf("go unknown null_style: %s", gc.NullStyle)
+	}
+	if err := gc.TypeOverrides.validate(); err != nil {
+		return gc, fmt.Errorf("go %s", err)
+	}
+	if gc.PackageName == "" {
+		gc.PackageName = filepath.Base(output)
+	}
+	return gc, nil
+}
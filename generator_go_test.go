@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"go/format"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestGoName(t *testing.T) {
+	ff := [][]string{
+		{"user_id", "UserID"},
+		{"foo_bar", "FooBar"},
+		{"api_url", "APIURL"},
+		{"in-progress", "InProgress"},
+		{"1st", "X1st"},
+	}
+	for _, d := range ff {
+		if actual := goName(d[0]); actual != d[1] {
+			t.Errorf("expected %s, actual: %s", d[1], actual)
+		}
+	}
+}
+
+func TestGoConvertType(t *testing.T) {
+	ins := InspectResult{Types: []Type{{Schema: "public", Name: "status"}}}
+	ff := []struct {
+		dataType string
+		notNull  bool
+		sqlType  string
+		ptrType  string
+	}{
+		{"text", true, "string", "string"},
+		{"text", false, "sql.NullString", "*string"},
+		{"bigint", false, "sql.NullInt64", "*int64"},
+		{"integer", true, "int32", "int32"},
+		{"timestamp(3) with time zone", false, "sql.NullTime", "*time.Time"},
+		{"numeric(12,4)", true, "string", "string"},
+		{"jsonb", false, "json.RawMessage", "json.RawMessage"},
+		{"bytea", false, "[]byte", "[]byte"},
+		{"integer[]", false, "pq.Int32Array", "[]int32"},
+		{"text[]", true, "pq.StringArray", "[]string"},
+		{"status[]", true, "pq.StringArray", "[]Status"},
+		{"status", true, "Status", "Status"},
+		{"status", false, "*Status", "*Status"},
+	}
+	for _, d := range ff {
+		col := Column{DataType: d.dataType, NotNull: d.notNull, Array: strings.HasSuffix(d.dataType, "[]")}
+		g := Go{config: GoConfig{NullStyle: GoNullStyleSQL}, ins: ins}
+		if actual := g.convertType(col); actual != d.sqlType {
+			t.Errorf("%s: expected %s, actual: %s", d.dataType, d.sqlType, actual)
+		}
+		g.config.NullStyle = GoNullStylePointer
+		if actual := g.convertType(col); actual != d.ptrType {
+			t.Errorf("%s: expected %s, actual: %s", d.dataType, d.ptrType, actual)
+		}
+	}
+}
+
+func TestGoBuild(t *testing.T) {
+	g := Go{
+		config: GoConfig{PackageName: "model", NullStyle: GoNullStyleSQL},
+		ins: InspectResult{
+			Types: []Type{{Schema: "public", Name: "status", Values: []string{"active", "in_review"}}},
+		},
+		template: template.Must(template.ParseGlob("templates/go/*.tmpl")),
+	}
+	table := Table{
+		Schema:  "public",
+		Name:    "user_account",
+		Comment: sql.NullString{String: "users", Valid: true},
+		Columns: []Column{
+			{Name: "id", DataType: "bigint", NotNull: true},
+			{Name: "email", DataType: "text"},
+			{Name: "status", DataType: "status", NotNull: true},
+			{Name: "created_at", DataType: "timestamp with time zone", NotNull: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := g.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("%s\n%s", err, buf.String())
+	}
+	out := string(src)
+	for _, expected := range []string{
+		"package model",
+		"\"database/sql\"\n\t\"time\"",
+		"type UserAccount struct {",
+		"ID        int64          `db:\"id\" json:\"id\"`",
+		"Email     sql.NullString `db:\"email\" json:\"email\"`",
+		"Status    Status         `db:\"status\" json:\"status\"`",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	if err := g.buildType(&buf, g.ins.Types); err != nil {
+		t.Fatal(err)
+	}
+	src, err = format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("%s\n%s", err, buf.String())
+	}
+	out = string(src)
+	for _, expected := range []string{
+		"type Status string",
+		"StatusActive   Status = \"active\"",
+		"StatusInReview Status = \"in_review\"",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	quoted := []Type{{Schema: "public", Name: "mark", Values: []string{`say "hi"`, `back\slash`}}}
+	if err := g.buildType(&buf, quoted); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := format.Source(buf.Bytes()); err != nil {
+		t.Fatalf("%s\n%s", err, buf.String())
+	}
+	if expected := `MarkSayHi Mark = "say \"hi\""`; !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q is not found in\n%s", expected, buf.String())
+	}
+
+	for _, values := range [][]string{{"in-review", "in_review"}, {"-1", "1"}} {
+		buf.Reset()
+		if err := g.buildType(&buf, []Type{{Schema: "public", Name: "status", Values: values}}); err == nil {
+			t.Errorf("%v: error expected on colliding names", values)
+		}
+	}
+}
+
+func TestGoImportsArray(t *testing.T) {
+	g := Go{config: GoConfig{NullStyle: GoNullStyleSQL}}
+	members := g.members(Table{Columns: []Column{{Name: "tags", DataType: "text[]", Array: true}}})
+	if imports := g.imports(members); len(imports) != 1 || imports[0] != "github.com/lib/pq" {
+		t.Errorf("unexpected imports: %v", imports)
+	}
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type GraphQLConfig struct {
+	Output       string   `json:"output"`
+	Templates    string   `json:"templates"`
+	FileName     string   `json:"file_name"`
+	IgnoreTables []string `json:"ignore_tables"`
+	// ForeignKeyObjects replaces foreign key columns with a field of the
+	// referenced type.
+	ForeignKeyObjects bool `json:"foreign_key_objects"`
+	// KeepSnakeCase uses column names as field names instead of lowerCamel.
+	KeepSnakeCase bool `json:"keep_snake_case"`
+
+	TypeOverrides TypeOverrides `json:"type_overrides"`
+}
+
+type GraphQL struct {
+	db       *sql.DB
+	config   GraphQLConfig
+	ins      InspectResult
+	template *template.Template
+	root     string
+}
+
+type GraphQLType struct {
+	Name         string
+	Comment      string
+	Fields       []GraphQLField
+	CreateFields []GraphQLField
+	UpdateFields []GraphQLField
+}
+
+type GraphQLField struct {
+	Name       string
+	Type       string
+	Comment    string
+	Directives string
+}
+
+type GraphQLEnum struct {
+	Name    string
+	Comment string
+	Values  []string
+}
+
+const GraphQLTypeName = "graphql"
+
+// graphQLBuiltinScalars need no declaration.
+var graphQLBuiltinScalars = []string{"ID", "String", "Int", "Float", "Boolean"}
+
+func NewGraphQL(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadGraphQLConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := GraphQL{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *GraphQL) GetType() string {
+	return GraphQLTypeName
+}
+
+func (gen *GraphQL) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	log.Printf("templates: %s", filePathJoinRoot(gen.root, gen.config.Templates))
+	gen.ins = ins
+
+	// Load templates
+	tdir := filepath.Join(filePathJoinRoot(gen.root, gen.config.Templates), "*.tmpl")
+	t := template.Must(template.ParseGlob(tdir))
+	gen.template = t
+
+	file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), gen.config.FileName))
+	if err != nil {
+		return errors.Wrap(err, "build create file")
+	}
+	defer file.Close()
+	if err := gen.buildSchema(file); err != nil {
+		return errors.Wrap(err, "build write schema")
+	}
+	return nil
+}
+
+func (gen *GraphQL) buildSchema(wr io.Writer) error {
+	var types []GraphQLType
+	for _, table := range gen.ins.Tables {
+		if gen.ignored(table.Name) {
+			continue
+		}
+		types = append(types, gen.buildTable(table))
+	}
+	var enums []GraphQLEnum
+	for _, typ := range gen.ins.Types {
+		// an enum without values is invalid SDL
+		if len(typ.Values) == 0 {
+			continue
+		}
+		enum, err := gen.buildType(typ)
+		if err != nil {
+			return err
+		}
+		enums = append(enums, enum)
+	}
+
+	return gen.template.ExecuteTemplate(wr, "schema", map[string]interface{}{
+		"scalars": gen.scalars(types),
+		"enums":   enums,
+		"types":   types,
+	})
+}
+
+func (gen *GraphQL) ignored(table string) bool {
+	return partContainsRegex(gen.config.IgnoreTables, table)
+}
+
+// scalars returns the custom scalars used by types.
+func (gen *GraphQL) scalars(types []GraphQLType) []string {
+	var ret []string
+	for _, typ := range types {
+		for _, f := range typ.Fields {
+			name := strings.Trim(f.Type, "[]!")
+			if contains(graphQLBuiltinScalars, name) || contains(ret, name) {
+				continue
+			}
+			if gen.isTypeName(name) {
+				continue
+			}
+			ret = append(ret, name)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// isTypeName reports whether name is an object type or an enum.
+func (gen *GraphQL) isTypeName(name string) bool {
+	for _, table := range gen.ins.Tables {
+		if gen.typeName(table.Schema, table.Name) == name {
+			return true
+		}
+	}
+	for _, typ := range gen.ins.Types {
+		if gen.typeName(typ.Schema, typ.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (gen *GraphQL) typeName(schema, name string) string {
+	return SnakeToUpperCamel(strings.Replace(qualifiedName(schema, name), ".", "_", -1))
+}
+
+func (gen *GraphQL) fieldName(name string) string {
+	if gen.config.KeepSnakeCase {
+		return name
+	}
+	return SnakeToLowerCamel(name)
+}
+
+func (gen *GraphQL) buildTable(table Table) GraphQLType {
+	ret := GraphQLType{
+		Name:    gen.typeName(table.Schema, table.Name),
+		Comment: graphQLDescription(table.Comment.String),
+	}
+
+	fks := gen.objectForeignKeys(table)
+	names := foreignKeyNames(table, fks)
+	hasPrimary := false
+	for _, col := range table.Columns {
+		t := gen.convertType(table, col)
+		if col.PrimaryKey {
+			hasPrimary = true
+		}
+
+		replaced := false
+		for _, fk := range fks {
+			if !contains(fk.Columns, col.Name) {
+				continue
+			}
+			replaced = true
+			if fk.Columns[0] == col.Name {
+				ret.Fields = append(ret.Fields, GraphQLField{
+					Name: gen.fieldName(names[fk.Name]),
+					Type: gen.nonNull(gen.typeName(fk.RefSchema, fk.RefTable), gen.foreignKeyNotNull(table, fk)),
+				})
+			}
+		}
+		if !replaced {
+			o, _ := gen.config.TypeOverrides.find(table, col)
+			ret.Fields = append(ret.Fields, GraphQLField{
+				Name:       gen.fieldName(col.Name),
+				Type:       gen.nonNull(t, col.NotNull),
+				Comment:    graphQLComment(col.Comment.String),
+				Directives: strings.Join(o.Annotations, " "),
+			})
+		}
+
+		if !generatedColumn(col) {
+			ret.CreateFields = append(ret.CreateFields, GraphQLField{
+				Name: gen.fieldName(col.Name),
+				Type: gen.nonNull(t, col.NotNull),
+			})
+		}
+		ret.UpdateFields = append(ret.UpdateFields, GraphQLField{
+			Name: gen.fieldName(col.Name),
+			Type: gen.nonNull(t, col.PrimaryKey),
+		})
+	}
+	if !hasPrimary {
+		// no way to identify the row to update
+		ret.UpdateFields = nil
+	}
+	return ret
+}
+
+// objectForeignKeys returns foreign keys of table which are represented as
+// object typed fields.
+func (gen *GraphQL) objectForeignKeys(table Table) []ForeignKey {
+	var ret []ForeignKey
+	if !gen.config.ForeignKeyObjects {
+		return ret
+	}
+	for _, fk := range table.ForeignKeys {
+		if gen.ignored(fk.RefTable) {
+			continue
+		}
+		if _, err := gen.ins.FindTable(fk.RefSchema, fk.RefTable); err != nil {
+			continue
+		}
+		ret = append(ret, fk)
+	}
+	return ret
+}
+
+func (gen *GraphQL) foreignKeyNotNull(table Table, fk ForeignKey) bool {
+	for _, col := range table.Columns {
+		if contains(fk.Columns, col.Name) && !col.NotNull {
+			return false
+		}
+	}
+	return true
+}
+
+func (gen *GraphQL) nonNull(t string, notNull bool) string {
+	if notNull {
+		return t + "!"
+	}
+	return t
+}
+
+func (gen *GraphQL) buildType(typ Type) (GraphQLEnum, error) {
+	var vs []string
+	labels := make(map[string]string)
+	for _, val := range typ.Values {
+		v := graphQLEnumValue(val)
+		if other, ok := labels[v]; ok {
+			return GraphQLEnum{}, fmt.Errorf("labels %q and %q of %s are both named %s", other, val, typ.Name, v)
+		}
+		labels[v] = val
+		vs = append(vs, v)
+	}
+	return GraphQLEnum{
+		Name:    gen.typeName(typ.Schema, typ.Name),
+		Comment: graphQLDescription(typ.Comment.String),
+		Values:  vs,
+	}, nil
+}
+
+// graphQLEnumValue converts an enum label to an upper snake case name.
+// Names must not start with a digit, so such labels and labels with neither
+// letters nor digits get "VALUE_".
+func graphQLEnumValue(val string) string {
+	name := SnakeToUpper(strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, val))
+	if strings.Trim(name, "_") == "" || unicode.IsDigit(rune(name[0])) {
+		name = "VALUE_" + name
+	}
+	return name
+}
+
+// graphQLDescription escapes s for a block string.
+func graphQLDescription(s string) string {
+	return strings.Replace(s, `"""`, `\"""`, -1)
+}
+
+// graphQLComment returns s as a single line description.
+func graphQLComment(s string) string {
+	if s == "" {
+		return ""
+	}
+	b, _ := json.Marshal(strings.Replace(s, "\n", " ", -1))
+	return string(b)
+}
+
+// convertType returns the named type of col without non-null marker.
+func (gen *GraphQL) convertType(table Table, col Column) string {
+	if o, ok := gen.config.TypeOverrides.find(table, col); ok {
+		return o.Type
+	}
+	t := strings.Replace(col.DataType, "[]", "", 1)
+	name := gen.scalarType(t)
+	if _, ok := table.FindForeignKey(col.Name); ok || col.PrimaryKey {
+		name = "ID"
+	}
+	if col.Array {
+		return "[" + name + "]"
+	}
+	return name
+}
+
+// graphQLTypes maps Postgres types to GraphQL scalars. Types which have no
+// counterpart are String, the text representation of Postgres.
+var graphQLTypes = map[PgType]string{
+	PgBool:        "Boolean",
+	PgInt16:       "Int",
+	PgInt32:       "Int",
+	PgInt64:       "BigInt",
+	PgFloat32:     "Float",
+	PgFloat64:     "Float",
+	PgNumeric:     "Decimal",
+	PgMoney:       "Decimal",
+	PgDate:        "Date",
+	PgTime:        "Time",
+	PgTimeTZ:      "Time",
+	PgTimestamp:   "DateTime",
+	PgTimestampTZ: "DateTime",
+	PgJSON:        "JSON",
+}
+
+func (gen *GraphQL) scalarType(t string) string {
+	if pt, ok := lookupPgType(t); ok {
+		if ret, ok := graphQLTypes[pt]; ok {
+			return ret
+		}
+		return "String"
+	}
+	typ, err := gen.ins.FindType(t)
+	if err == nil {
+		if len(typ.Values) == 0 {
+			return "String"
+		}
+		return gen.typeName(typ.Schema, typ.Name)
+	}
+	warnUnknownType(GraphQLTypeName, t, "String")
+	return "String"
+}
+
+func loadGraphQLConfig(root string, raw json.RawMessage) (GraphQLConfig, error) {
+	var gc GraphQLConfig
+	if err := json.Unmarshal(raw, &gc); err != nil {
+		return gc, fmt.Errorf("graphql config error: %s", err)
+	}
+	output := filePathJoinRoot(root, gc.Output)
+	if err := DirExists(output); err != nil {
+		return gc, fmt.Errorf("graphql output is not exists: %s", gc.Output)
+	}
+	if gc.FileName == "" {
+		gc.FileName = "schema.graphql"
+	}
+	if err := gc.TypeOverrides.validate(); err != nil {
+		return gc, fmt.Errorf("graphql %s", err)
+	}
+	return gc, nil
+}
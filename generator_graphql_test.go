@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestGraphQLBuildSchema(t *testing.T) {
+	ins := relationInspectResult()
+	ins.Tables[1].Columns = append(ins.Tables[1].Columns,
+		Column{Name: "status", DataType: "order_status", NotNull: true},
+		Column{Name: "ordered_at", DataType: "timestamp with time zone", DefaultValue: sql.NullString{String: "now()", Valid: true}},
+		Column{Name: "note", DataType: "text", Comment: sql.NullString{String: `free "text"`, Valid: true}},
+	)
+	ins.Types = []Type{{Schema: "public", Name: "order_status", Values: []string{"open", "in-review", "1"}}}
+	gen := GraphQL{
+		config:   GraphQLConfig{ForeignKeyObjects: true},
+		ins:      ins,
+		template: template.Must(template.ParseGlob("templates/graphql/*.tmpl")),
+	}
+
+	var buf bytes.Buffer
+	if err := gen.buildSchema(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"\nscalar DateTime\n",
+		"enum OrderStatus {\n  OPEN\n  IN_REVIEW\n  VALUE_1\n}",
+		"type OrderItem {\n  id: ID!\n  customer: Customer!\n",
+		"  status: OrderStatus!\n  orderedAt: DateTime\n  \"free \\\"text\\\"\"\n  note: String\n}",
+		"input CreateOrderItemInput {\n  id: ID!\n  customerId: ID!\n  status: OrderStatus!\n  note: String\n}",
+		"input UpdateOrderItemInput {\n  id: ID!\n  customerId: ID\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	gen.config.ForeignKeyObjects = false
+	buf.Reset()
+	if err := gen.buildSchema(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "type OrderItem {\n  id: ID!\n  customerId: ID!\n") {
+		t.Errorf("foreign key should be scalar\n%s", buf.String())
+	}
+}
+
+func TestGraphQLEnumValue(t *testing.T) {
+	ff := [][]string{
+		{"open", "OPEN"},
+		{"in-review", "IN_REVIEW"},
+		{"2fa", "VALUE_2FA"},
+		{"1", "VALUE_1"},
+		{"1.5", "VALUE_1_5"},
+		{"", "VALUE_"},
+		{"-", "VALUE__"},
+	}
+	for _, d := range ff {
+		if actual := graphQLEnumValue(d[0]); actual != d[1] {
+			t.Errorf("%q: expected %s, actual: %s", d[0], d[1], actual)
+		}
+	}
+
+	gen := GraphQL{}
+	for _, values := range [][]string{{"in-review", "in_review"}, {"a b", "a-b"}} {
+		if _, err := gen.buildType(Type{Name: "status", Values: values}); err == nil {
+			t.Errorf("%v: expected an error", values)
+		}
+	}
+}
+
+func TestGraphQLEmptyEnum(t *testing.T) {
+	ins := InspectResult{
+		Tables: []Table{{
+			Schema:  "public",
+			Name:    "account",
+			Columns: []Column{{Name: "state", DataType: "state", NotNull: true}},
+		}},
+		Types: []Type{{Schema: "public", Name: "state"}},
+	}
+	gen := GraphQL{
+		ins:      ins,
+		template: template.Must(template.ParseGlob("templates/graphql/*.tmpl")),
+	}
+
+	var buf bytes.Buffer
+	if err := gen.buildSchema(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "enum State") {
+		t.Errorf("enum without values should be skipped\n%s", out)
+	}
+	if expected := "  state: String!\n"; !strings.Contains(out, expected) {
+		t.Errorf("%q is not found in\n%s", expected, out)
+	}
+}
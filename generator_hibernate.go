@@ -7,9 +7,9 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -21,7 +21,7 @@ import (
 type HibernateConfig struct {
 	Output               string   `json:"output"`
 	Templates            string   `json:"templates"`
-	Overwrites           []string `json:"overwrites"`
+	Overwrites           []string `json:"overwrites"` // files (regexp) which may be replaced entirely
 	PackageName          string   `json:"package_name"`
 	IgnoreTables         []string `json:"ignore_tables"`
 	NotInsertableColumns []string `json:"not_insertable_columns"`
@@ -29,6 +29,51 @@ type HibernateConfig struct {
 	IgnoreColumns        []string `json:"ignore_columns"`
 	GenerateMetamodel    bool     `json:"generate_metamodel"`
 	VersionFieldColumn   string   `json:"version_field_column"`
+
+	Relationships HibernateRelationshipConfig `json:"relationships"`
+	// CompositeKeyStrategy is how a multi column primary key is mapped,
+	// "id_class" (default) or "embedded_id".
+	CompositeKeyStrategy string `json:"composite_key_strategy"`
+	// BeanValidation adds Bean Validation constraints of the package,
+	// "javax" or "jakarta". Empty disables them.
+	BeanValidation string `json:"bean_validation"`
+	// JpaNamespace is the package of Jakarta Persistence, "javax" (default)
+	// or "jakarta".
+	JpaNamespace string `json:"jpa_namespace"`
+	// HibernateVersion is the major version of Hibernate, 5 (default) or 6.
+	// Hibernate 6 requires "jakarta".
+	HibernateVersion int `json:"hibernate_version"`
+	// EnumMapping is how enum columns are mapped, "usertype" (default) by a
+	// UserType per enum, or "converter" by an AttributeConverter.
+	EnumMapping string `json:"enum_mapping"`
+	// AccessorStyle is how properties are accessed, "plain" (default)
+	// getters and setters, "lombok" or "record".
+	AccessorStyle string `json:"accessor_style"`
+
+	TypeOverrides TypeOverrides `json:"type_overrides"`
+}
+
+const (
+	HibernateIdClass    = "id_class"
+	HibernateEmbeddedId = "embedded_id"
+
+	HibernateJavax   = "javax"
+	HibernateJakarta = "jakarta"
+
+	HibernateUserType  = "usertype"
+	HibernateConverter = "converter"
+
+	HibernatePlain  = "plain"
+	HibernateLombok = "lombok"
+	HibernateRecord = "record"
+)
+
+type HibernateRelationshipConfig struct {
+	// ManyToOne maps foreign key columns as @ManyToOne associations.
+	ManyToOne bool `json:"many_to_one"`
+	// OneToMany is a list of foreign key names (regexp) whose referenced
+	// entity gets the inverse @OneToMany collection.
+	OneToMany []string `json:"one_to_many"`
 }
 
 type Hibernate struct {
@@ -40,9 +85,11 @@ type Hibernate struct {
 }
 
 type HibernateMember struct {
-	Name    string
-	Type    string
-	Comment string
+	Name       string
+	Type       string
+	Comment    string
+	Init       string
+	Anotations []string // annotations of the field, only for lombok
 }
 
 type HibernateMetamodel struct {
@@ -88,64 +135,92 @@ func (gen *Hibernate) Build(ins InspectResult) error {
 	t := template.Must(template.ParseGlob(tdir))
 	gen.template = t
 
+	out := &regionWriter{
+		dir:        filePathJoinRoot(gen.root, gen.config.Output),
+		overwrites: gen.config.Overwrites,
+	}
+	if err := checkNames(gen.ins, gen.config.IgnoreTables, SnakeToUpperCamel); err != nil {
+		return errors.Wrap(err, "build")
+	}
+
 	// Build tables
 	for _, table := range gen.ins.Tables {
 		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
 			continue
 		}
 
+		var buf bytes.Buffer
 		fileName := SnakeToUpperCamel(table.Name) + ".java"
-		file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), fileName))
-		if err != nil {
-			return errors.Wrap(err, "build create file")
+		if gen.config.AccessorStyle == HibernateRecord {
+			// a record is a projection, which has no id class nor metamodel
+			if err := gen.buildRecord(&buf, table); err != nil {
+				return errors.Wrap(err, "build write record")
+			}
+			if _, err := out.write(fileName, buf.Bytes()); err != nil {
+				return errors.Wrap(err, "build write file")
+			}
+			continue
 		}
-		if err := gen.buildTable(file, table); err != nil {
-			file.Close()
+		if err := gen.buildTable(&buf, table); err != nil {
 			return errors.Wrap(err, "build write table")
 		}
+		if _, err := out.write(fileName, buf.Bytes()); err != nil {
+			return errors.Wrap(err, "build write file")
+		}
+
+		if compositeKey(table) {
+			buf.Reset()
+			if err := gen.buildIdClass(&buf, table); err != nil {
+				return errors.Wrap(err, "build write id class")
+			}
+			idFileName := gen.idClassName(table) + ".java"
+			if _, err := out.write(idFileName, buf.Bytes()); err != nil {
+				return errors.Wrap(err, "build write id class file")
+			}
+		}
 
 		if gen.config.GenerateMetamodel {
 			// generate meta model class file
-			metaFileName := SnakeToUpperCamel(table.Name) + "_.java"
-			metaFile, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), metaFileName))
-			if err != nil {
-				file.Close()
-				return errors.Wrap(err, "create metamodel file")
-			}
-			if err := gen.buildMetamodel(metaFile, table); err != nil {
-				file.Close()
-				metaFile.Close()
+			buf.Reset()
+			if err := gen.buildMetamodel(&buf, table); err != nil {
 				return errors.Wrap(err, "build write metamodel")
 			}
-			metaFile.Close()
+			metaFileName := SnakeToUpperCamel(table.Name) + "_.java"
+			if _, err := out.write(metaFileName, buf.Bytes()); err != nil {
+				return errors.Wrap(err, "build write metamodel file")
+			}
 		}
-		file.Close()
 	}
 
 	// Build types
 	for _, typ := range gen.ins.Types {
+		var buf, utBuf bytes.Buffer
+		if err := gen.buildType(&buf, &utBuf, typ); err != nil {
+			return errors.Wrap(err, "build write type")
+		}
 		fileName := SnakeToUpperCamel(typ.Name) + ".java"
-		file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), fileName))
-		if err != nil {
-			return errors.Wrap(err, "build create file")
+		if _, err := out.write(fileName, buf.Bytes()); err != nil {
+			return errors.Wrap(err, "build write file")
+		}
+		if gen.converter() {
+			continue
 		}
-
 		utFileName := SnakeToUpperCamel(typ.Name) + "UserType.java"
-		utFile, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), utFileName))
-		if err != nil {
-			file.Close()
+		if _, err := out.write(utFileName, utBuf.Bytes()); err != nil {
 			return errors.Wrap(err, "build usertype file")
 		}
-
-		if err := gen.buildType(file, utFile, typ); err != nil {
-			file.Close()
-			utFile.Close()
-			return errors.Wrap(err, "build write type")
+	}
+	if gen.converter() && len(gen.ins.Types) > 0 {
+		var buf bytes.Buffer
+		if err := gen.buildEnumConverter(&buf); err != nil {
+			return errors.Wrap(err, "build write enum converter")
+		}
+		if _, err := out.write("PgEnumConverter.java", buf.Bytes()); err != nil {
+			return errors.Wrap(err, "build write file")
 		}
-		file.Close()
 	}
 
-	return nil
+	return out.err()
 }
 
 func (gen *Hibernate) buildTable(wr io.Writer, table Table) error {
@@ -156,6 +231,66 @@ func (gen *Hibernate) buildTable(wr io.Writer, table Table) error {
 		"name":         SnakeToUpperCamel(table.Name),
 		"member":       gen.members(table),
 		"accessor":     gen.accessor(table),
+		"id_class":     gen.idClassAnnotation(table),
+		"imports":      gen.imports(gen.properties(table)),
+		"jpa":          gen.jpaNamespace(),
+		"hibernate6":   gen.hibernate6(),
+		"lombok":       gen.lombok(),
+		"equals_id":    gen.lombok() && len(table.PrimaryKeys) > 0,
+	})
+}
+
+func (gen *Hibernate) buildIdClass(wr io.Writer, table Table) error {
+	var members []HibernateMember
+	props := gen.idProperties(table)
+	for _, p := range props {
+		m := HibernateMember{
+			Name:    p.Name,
+			Type:    p.Type,
+			Comment: p.Comment,
+		}
+		if gen.lombok() {
+			m.Anotations = gen.idAnotations(table, p)
+		}
+		members = append(members, m)
+	}
+	return gen.template.ExecuteTemplate(wr, "id_class", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+		"table":        table,
+		"entity":       SnakeToUpperCamel(table.Name),
+		"name":         gen.idClassName(table),
+		"embeddable":   gen.embeddedId(table),
+		"member":       members,
+		"accessor":     gen.idAccessor(table),
+		"imports":      gen.imports(props),
+		"jpa":          gen.jpaNamespace(),
+		"hibernate6":   gen.hibernate6(),
+		"lombok":       gen.lombok(),
+	})
+}
+
+// buildRecord writes table as an immutable record for read only projections.
+// Foreign keys are kept as columns since a record cannot be lazily loaded.
+func (gen *Hibernate) buildRecord(wr io.Writer, table Table) error {
+	var members []HibernateMember
+	var props []HibernateProperty
+	for _, col := range table.Columns {
+		p := gen.columnProperty(table, col)
+		props = append(props, p)
+		members = append(members, HibernateMember{
+			Name:    p.Name,
+			Type:    p.Type,
+			Comment: p.Comment,
+		})
+	}
+	return gen.template.ExecuteTemplate(wr, "record", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+		"table":        table,
+		"name":         SnakeToUpperCamel(table.Name),
+		"member":       members,
+		"imports":      gen.overrideImports(props),
 	})
 }
 
@@ -164,26 +299,294 @@ func (gen *Hibernate) buildMetamodel(wr io.Writer, table Table) error {
 		"package_name": gen.config.PackageName,
 		"name":         SnakeToUpperCamel(table.Name),
 		"member":       gen.metamodel(table),
+		"jpa":          gen.jpaNamespace(),
 	})
 }
 
-func (gen *Hibernate) members(table Table) []HibernateMember {
-	var ret []HibernateMember
-	hasPrimary := false
+// hibernate6 reports whether the output is for Hibernate 6, which replaced
+// @Type(type = "...") by @Type(Class) and @JdbcTypeCode.
+func (gen *Hibernate) hibernate6() bool {
+	return gen.config.HibernateVersion == 6
+}
+
+// HibernateProperty is a property of an entity. It is mapped either to a
+// column, or to an association when ForeignKey is set.
+type HibernateProperty struct {
+	Name       string
+	Type       string
+	Comment    string
+	Column     *Column
+	ForeignKey *ForeignKey
+	OneToMany  bool
+	ReadOnly   bool // association which does not own its join columns
+	EmbeddedId bool
+	Override   *TypeOverride
+}
+
+// funcName returns the accessor name without get/set prefix.
+func (p HibernateProperty) funcName() string {
+	if p.Column != nil {
+		return SnakeToUpperCamel(p.Column.Name)
+	}
+	return strings.Title(p.Name)
+}
+
+// properties returns the properties of table in column order. Join columns
+// of a @ManyToOne association are replaced by the association, and
+// @OneToMany collections come last.
+func (gen *Hibernate) properties(table Table) []HibernateProperty {
+	var ret []HibernateProperty
+	manyToOnes := gen.manyToOnes(table)
+	names := gen.manyToOneNames(table, manyToOnes)
+
+	for i := range table.Columns {
+		col := table.Columns[i]
+		owned := false
+		for j := range manyToOnes {
+			fk := manyToOnes[j]
+			if !contains(fk.Columns, col.Name) || gen.readOnlyForeignKey(table, fk, manyToOnes) {
+				continue
+			}
+			owned = true
+			if fk.Columns[0] == col.Name {
+				ret = append(ret, gen.manyToOneProperty(names[fk.Name], fk, false))
+			}
+		}
+		if owned {
+			continue
+		}
+
+		if gen.embeddedId(table) && col.PrimaryKey {
+			if col.Name == table.PrimaryKeys[0].Name {
+				ret = append(ret, HibernateProperty{
+					Name:       gen.embeddedIdName(table),
+					Type:       gen.idClassName(table),
+					Comment:    "primary key",
+					EmbeddedId: true,
+				})
+			}
+		} else {
+			ret = append(ret, gen.columnProperty(table, col))
+		}
+
+		for j := range manyToOnes {
+			fk := manyToOnes[j]
+			last := fk.Columns[len(fk.Columns)-1]
+			if last == col.Name && gen.readOnlyForeignKey(table, fk, manyToOnes) {
+				ret = append(ret, gen.manyToOneProperty(names[fk.Name], fk, true))
+			}
+		}
+	}
+
+	var used []string
+	for _, p := range ret {
+		used = append(used, p.Name)
+	}
+	for _, fk := range gen.oneToManys(table) {
+		fk := fk
+		name := pluralize(SnakeToLowerCamel(fk.Table))
+		if contains(used, name) {
+			name = SnakeToLowerCamel(fk.Name) + "List"
+		}
+		used = append(used, name)
+		ret = append(ret, HibernateProperty{
+			Name:       name,
+			Type:       fmt.Sprintf("List<%s>", SnakeToUpperCamel(fk.Table)),
+			Comment:    fk.Name,
+			ForeignKey: &fk,
+			OneToMany:  true,
+		})
+	}
+	return ret
+}
 
+func (gen *Hibernate) columnProperty(table Table, col Column) HibernateProperty {
+	p := HibernateProperty{
+		Name:    SnakeToLowerCamel(col.Name),
+		Comment: strings.Replace(col.Comment.String, "\n", "", -1),
+		Column:  &col,
+	}
+	if o, ok := gen.config.TypeOverrides.find(table, col); ok {
+		p.Type = o.Type
+		p.Override = &o
+		return p
+	}
+	p.Type = gen.convertType(col)
+	if col.Array {
+		p.Type = fmt.Sprintf("%s[]", p.Type)
+	}
+	return p
+}
+
+// imports returns the imports of type_overrides and validation constraints
+// used by props.
+func (gen *Hibernate) imports(props []HibernateProperty) []string {
+	ret := gen.overrideImports(props)
+	for _, i := range gen.validationImports(props) {
+		if !contains(ret, i) {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// overrideImports returns the imports of type_overrides used by props.
+func (gen *Hibernate) overrideImports(props []HibernateProperty) []string {
+	var ret []string
+	for _, p := range props {
+		if p.Override == nil {
+			continue
+		}
+		for _, i := range p.Override.Imports {
+			if !contains(ret, i) {
+				ret = append(ret, i)
+			}
+		}
+	}
+	return ret
+}
+
+// idClassAnnotation returns the class name for @IdClass, or empty if the
+// entity does not use one.
+func (gen *Hibernate) idClassAnnotation(table Table) string {
+	if !compositeKey(table) || gen.embeddedId(table) {
+		return ""
+	}
+	return gen.idClassName(table)
+}
+
+// compositeKey reports whether table has a multi column primary key.
+func compositeKey(table Table) bool {
+	return len(table.PrimaryKeys) > 1
+}
+
+// embeddedId reports whether the primary key of table is mapped as an
+// @EmbeddedId property.
+func (gen *Hibernate) embeddedId(table Table) bool {
+	return compositeKey(table) && gen.config.CompositeKeyStrategy == HibernateEmbeddedId
+}
+
+func (gen *Hibernate) idClassName(table Table) string {
+	return SnakeToUpperCamel(table.Name) + "Id"
+}
+
+// embeddedIdName returns the property name of the @EmbeddedId, "id" unless
+// the table has a column with that name.
+func (gen *Hibernate) embeddedIdName(table Table) string {
 	for _, col := range table.Columns {
-		t := gen.convertType(col)
-		if col.Array {
-			t = fmt.Sprintf("%s[]", t)
+		if col.Name == "id" {
+			return "pk"
+		}
+	}
+	return "id"
+}
+
+// idProperties returns the properties of the primary key class.
+func (gen *Hibernate) idProperties(table Table) []HibernateProperty {
+	var ret []HibernateProperty
+	for _, col := range table.PrimaryKeys {
+		ret = append(ret, gen.columnProperty(table, col))
+	}
+	return ret
+}
+
+func (gen *Hibernate) manyToOneProperty(name string, fk ForeignKey, readOnly bool) HibernateProperty {
+	return HibernateProperty{
+		Name:       name,
+		Type:       SnakeToUpperCamel(fk.RefTable),
+		Comment:    fk.Name,
+		ForeignKey: &fk,
+		ReadOnly:   readOnly,
+	}
+}
+
+// manyToOnes returns the foreign keys of table mapped as @ManyToOne.
+func (gen *Hibernate) manyToOnes(table Table) []ForeignKey {
+	var ret []ForeignKey
+	if !gen.config.Relationships.ManyToOne {
+		return ret
+	}
+	for _, fk := range table.ForeignKeys {
+		if partContainsRegex(gen.config.IgnoreTables, fk.RefTable) {
+			continue
+		}
+		if _, err := gen.ins.FindTable(fk.RefSchema, fk.RefTable); err != nil {
+			continue
+		}
+		ret = append(ret, fk)
+	}
+	return ret
+}
+
+// oneToManys returns the foreign keys referencing table which are mapped
+// as inverse @OneToMany collections.
+func (gen *Hibernate) oneToManys(table Table) []ForeignKey {
+	var ret []ForeignKey
+	if !gen.config.Relationships.ManyToOne || len(gen.config.Relationships.OneToMany) == 0 {
+		return ret
+	}
+	for _, fk := range gen.ins.ReferencedBy(table) {
+		if partContainsRegex(gen.config.IgnoreTables, fk.Table) {
+			continue
+		}
+		if !partContainsRegex(gen.config.Relationships.OneToMany, fk.Name) {
+			continue
+		}
+		ret = append(ret, fk)
+	}
+	return ret
+}
+
+// readOnlyForeignKey reports whether the association of fk must not write
+// its join columns. That is the case when a join column is also a primary
+// key column or is shared with another association, then the column stays
+// a scalar property which owns the value.
+func (gen *Hibernate) readOnlyForeignKey(table Table, fk ForeignKey, manyToOnes []ForeignKey) bool {
+	for _, col := range table.Columns {
+		if !contains(fk.Columns, col.Name) {
+			continue
 		}
 		if col.PrimaryKey {
+			return true
+		}
+		for _, other := range manyToOnes {
+			if other.Name != fk.Name && contains(other.Columns, col.Name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// manyToOneNames returns property names of associations keyed by the
+// foreign key name.
+func (gen *Hibernate) manyToOneNames(table Table, fks []ForeignKey) map[string]string {
+	ret := foreignKeyNames(table, fks)
+	for k, v := range ret {
+		ret[k] = SnakeToLowerCamel(v)
+	}
+	return ret
+}
+
+func (gen *Hibernate) members(table Table) []HibernateMember {
+	var ret []HibernateMember
+	hasPrimary := false
+
+	for _, p := range gen.properties(table) {
+		if p.EmbeddedId || (p.Column != nil && p.Column.PrimaryKey) {
 			hasPrimary = true
 		}
 
 		m := HibernateMember{
-			Name:    SnakeToLowerCamel(col.Name),
-			Type:    t,
-			Comment: strings.Replace(col.Comment.String, "\n", "", -1),
+			Name:    p.Name,
+			Type:    p.Type,
+			Comment: p.Comment,
+		}
+		if p.OneToMany {
+			m.Init = "new ArrayList<>()"
+		}
+		if gen.lombok() {
+			m.Anotations = gen.fieldAnotations(table, p)
 		}
 		ret = append(ret, m)
 	}
@@ -196,19 +599,23 @@ func (gen *Hibernate) members(table Table) []HibernateMember {
 
 func (gen *Hibernate) metamodel(table Table) []HibernateMetamodel {
 	var ret []HibernateMetamodel
-	for _, col := range table.Columns {
-		t := gen.convertType(col)
-		attr := "SingularAttribute" // Only Singular is used
-
-		typ := strings.Title(t)
-		if col.Array {
-			typ = typ + "[]"
+	for _, p := range gen.properties(table) {
+		attr := "SingularAttribute"
+		var typ string
+		switch {
+		case p.OneToMany:
+			attr = "ListAttribute"
+			typ = SnakeToUpperCamel(p.ForeignKey.Table)
+		case p.ForeignKey != nil, p.EmbeddedId:
+			typ = p.Type
+		default:
+			typ = strings.Title(p.Type)
 		}
 
 		m := HibernateMetamodel{
 			Attr:    attr,
 			ClsName: SnakeToUpperCamel(table.Name),
-			Name:    decapitalize(SnakeToUpperCamel(col.Name)),
+			Name:    decapitalize(p.funcName()),
 			Type:    typ,
 		}
 		ret = append(ret, m)
@@ -234,16 +641,19 @@ func startsWithSeveralUpperCaseLetters(str string) bool {
 }
 
 func (gen *Hibernate) accessor(table Table) []string {
+	if gen.lombok() {
+		return nil
+	}
 	var ret []string
 
-	for _, col := range table.Columns {
-		getter, err := gen.getter(col)
+	for _, p := range gen.properties(table) {
+		getter, err := gen.getter(p, gen.propertyAnotations(table, p))
 		if err != nil {
 			log.Fatal(err)
 		}
 		ret = append(ret, getter)
 
-		setter, err := gen.setter(col)
+		setter, err := gen.setter(p)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -252,30 +662,91 @@ func (gen *Hibernate) accessor(table Table) []string {
 	return ret
 }
 
-func (gen *Hibernate) getter(col Column) (string, error) {
-	var ret bytes.Buffer
-	t := gen.convertType(col)
-	if col.Array {
-		t = fmt.Sprintf("%s[]", t)
+// idAccessor returns accessors of the primary key class.
+func (gen *Hibernate) idAccessor(table Table) []string {
+	if gen.lombok() {
+		return nil
 	}
+	var ret []string
+
+	for _, p := range gen.idProperties(table) {
+		getter, err := gen.getter(p, gen.idAnotations(table, p))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ret = append(ret, getter)
+
+		setter, err := gen.setter(p)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ret = append(ret, setter)
+	}
+	return ret
+}
+
+// idAnotations returns the annotations of a property of the primary key
+// class. Only an @Embeddable class carries the column mappings.
+func (gen *Hibernate) idAnotations(table Table, p HibernateProperty) []string {
+	if !gen.embeddedId(table) {
+		return nil
+	}
+	var ret []string
+	for _, a := range gen.anotations(p) {
+		if a == "@Id" || strings.HasPrefix(a, "@GeneratedValue") {
+			continue
+		}
+		ret = append(ret, a)
+	}
+	return ret
+}
+
+// lombok reports whether accessors are generated by Lombok. Mappings are
+// written on fields instead of getters.
+func (gen *Hibernate) lombok() bool {
+	return gen.config.AccessorStyle == HibernateLombok
+}
+
+// fieldAnotations returns the annotations of a field for Lombok. Primary
+// keys are included in equals and hashCode, and read only columns have no
+// setter.
+func (gen *Hibernate) fieldAnotations(table Table, p HibernateProperty) []string {
+	var ret []string
+	if p.EmbeddedId || (p.Column != nil && p.Column.PrimaryKey) {
+		ret = append(ret, "@EqualsAndHashCode.Include")
+	}
+	if col := p.Column; col != nil && contains(gen.config.NotInsertableColumns, col.Name) && contains(gen.config.NotUpdatableColumns, col.Name) {
+		ret = append(ret, "@Setter(AccessLevel.NONE)")
+	}
+	return append(ret, gen.propertyAnotations(table, p)...)
+}
+
+func (gen *Hibernate) propertyAnotations(table Table, p HibernateProperty) []string {
+	switch {
+	case p.EmbeddedId:
+		return []string{"@EmbeddedId"}
+	case p.ForeignKey != nil:
+		return gen.relationAnotations(table, p)
+	default:
+		return gen.anotations(p)
+	}
+}
+
+func (gen *Hibernate) getter(p HibernateProperty, anotations []string) (string, error) {
+	var ret bytes.Buffer
 	data := map[string]interface{}{
-		"func":       SnakeToUpperCamel(col.Name),
-		"name":       SnakeToLowerCamel(col.Name),
-		"type":       t,
-		"anotations": gen.anotations(col),
+		"func":       p.funcName(),
+		"name":       p.Name,
+		"type":       p.Type,
+		"anotations": anotations,
 	}
 	if err := gen.template.ExecuteTemplate(&ret, "getter", data); err != nil {
-		return "", errors.Wrap(err, "getter: "+col.Name)
+		return "", errors.Wrap(err, "getter: "+p.Name)
 	}
 
 	return ret.String(), nil
 }
 
-func parseForignTable(src string) (string, string) {
-	// FOREIGN KEY (security_code) REFERENCES master_security(security_code)
-	return "", ""
-}
-
 var regNextval = regexp.MustCompile(`^nextval\('.+_seq'::regclass\)`)
 
 func isSequence(col Column) bool {
@@ -285,7 +756,8 @@ func isSequence(col Column) bool {
 	return false
 }
 
-func (gen *Hibernate) anotations(col Column) []string {
+func (gen *Hibernate) anotations(p HibernateProperty) []string {
+	col := *p.Column
 	var ret []string
 	if col.PrimaryKey {
 		ret = append(ret, "@Id")
@@ -293,18 +765,80 @@ func (gen *Hibernate) anotations(col Column) []string {
 	if col.Unique {
 		ret = append(ret, "@UniqueConstraint")
 	}
-	if col.ForignTable.Valid {
-		// a := `@JoinColumns({ @JoinColumn(name="userid", referencedColumnName="id") })`
-		// ret = append(ret, "// ForignTable = "+col.ForignTable.String)
-	}
 	if col.Serial || isSequence(col) {
 		ret = append(ret, "@GeneratedValue(strategy=GenerationType.IDENTITY)")
 	}
 
-	if gen.enumExists(col.DataType) {
+	if p.Override != nil {
+		// the user type of the overridden type is given by annotations
+		ret = append(ret, p.Override.Annotations...)
+	} else {
+		ret = append(ret, gen.typeAnotations(col)...)
+	}
+
+	if gen.config.VersionFieldColumn == col.Name {
+		ret = append(ret, fmt.Sprintf("@%s.persistence.Version", gen.jpaNamespace()))
+	}
+
+	column_args := make([]string, 0)
+	column_args = append(column_args, fmt.Sprintf(`name="%s"`, col.Name))
+	column_args = append(column_args, fmt.Sprintf("nullable=%t", !col.NotNull))
+	if typ, ok := gen.enumConverter(col); ok && p.Override == nil {
+		column_args = append(column_args, fmt.Sprintf(`columnDefinition="%s"`, qualifiedName(typ.Schema, typ.Name)))
+	}
+	if col.Length > 0 && !col.Array {
+		column_args = append(column_args, fmt.Sprintf("length=%d", col.Length))
+	}
+	if col.Precision > 0 && !col.Array {
+		column_args = append(column_args, fmt.Sprintf("precision=%d", col.Precision))
+		column_args = append(column_args, fmt.Sprintf("scale=%d", col.Scale))
+	}
+	if contains(gen.config.NotInsertableColumns, col.Name) {
+		column_args = append(column_args, "insertable=false")
+	}
+	if contains(gen.config.NotUpdatableColumns, col.Name) {
+		column_args = append(column_args, "updatable=false")
+	}
+
+	ret = append(ret, fmt.Sprintf(`@Column(%s)`, strings.Join(column_args, ", ")))
+	ret = append(ret, gen.validations(p)...)
+
+	return ret
+}
+
+// typeAnotations returns the annotations which map enum, json and array
+// columns to their user types, and cast String columns of other types.
+func (gen *Hibernate) typeAnotations(col Column) []string {
+	if typ, ok := gen.enumConverter(col); ok {
+		// the label is bound as varchar, which needs a cast to the enum
+		return []string{
+			fmt.Sprintf("@Convert(converter = %s.Converter.class)", SnakeToUpperCamel(typ.Name)),
+			fmt.Sprintf(`@ColumnTransformer(write = "?::%s")`, qualifiedName(typ.Schema, typ.Name)),
+		}
+	}
+
+	var ret []string
+	if castString(col) {
+		// a String is bound as varchar, which is not assignable to the type
+		ret = append(ret, fmt.Sprintf(`@ColumnTransformer(write = "?::%s")`, col.DataType))
+	}
+	if gen.hibernate6() {
+		if typ, err := gen.ins.FindType(col.DataType); err == nil {
+			ret = append(ret, fmt.Sprintf("@Type(%sUserType.class)", SnakeToUpperCamel(typ.Name)))
+		}
+		if col.DataType == "json" || col.DataType == "jsonb" {
+			ret = append(ret, "@JdbcTypeCode(SqlTypes.JSON)")
+		}
+		if col.Array {
+			ret = append(ret, "@JdbcTypeCode(SqlTypes.ARRAY)")
+		}
+		return ret
+	}
+
+	if typ, err := gen.ins.FindType(col.DataType); err == nil {
 		ret = append(ret, fmt.Sprintf(`@Type(type = "%s.%sUserType")`,
 			gen.config.PackageName,
-			SnakeToUpperCamel(col.DataType)))
+			SnakeToUpperCamel(typ.Name)))
 	}
 
 	if col.DataType == "json" || col.DataType == "jsonb" {
@@ -315,66 +849,146 @@ func (gen *Hibernate) anotations(col Column) []string {
 		t := strings.Title(gen.convertType(col))
 		ret = append(ret, fmt.Sprintf(`@Type(type = "%sArrayUserType")`, t))
 	}
+	return ret
+}
 
-	if gen.config.VersionFieldColumn == col.Name {
-		ret = append(ret, fmt.Sprintf("@javax.persistence.Version"))
+// converter reports whether enums are mapped by AttributeConverters.
+func (gen *Hibernate) converter() bool {
+	return gen.config.EnumMapping == HibernateConverter
+}
+
+// buildEnumConverter writes PgEnumConverter, the base class of the
+// converters of all enums.
+func (gen *Hibernate) buildEnumConverter(wr io.Writer) error {
+	return gen.template.ExecuteTemplate(wr, "enum_converter", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+		"jpa":          gen.jpaNamespace(),
+	})
+}
+
+// enumConverter returns the enum type of col if it is mapped by an
+// AttributeConverter.
+func (gen *Hibernate) enumConverter(col Column) (Type, bool) {
+	if !gen.converter() {
+		return Type{}, false
 	}
+	typ, err := gen.ins.FindType(col.DataType)
+	return typ, err == nil
+}
 
-	column_args := make([]string, 0)
-	column_args = append(column_args, fmt.Sprintf(`name="%s"`, col.Name))
-	column_args = append(column_args, fmt.Sprintf("nullable=%t", !col.NotNull))
-	if contains(gen.config.NotInsertableColumns, col.Name) {
-		column_args = append(column_args, "insertable=false")
+// jpaNamespace returns the package prefix of Jakarta Persistence.
+func (gen *Hibernate) jpaNamespace() string {
+	if gen.config.JpaNamespace == "" {
+		return HibernateJavax
 	}
-	if contains(gen.config.NotUpdatableColumns, col.Name) {
-		column_args = append(column_args, "updatable=false")
+	return gen.config.JpaNamespace
+}
+
+// relationAnotations returns the annotations of an association property.
+func (gen *Hibernate) relationAnotations(table Table, p HibernateProperty) []string {
+	fk := p.ForeignKey
+	if p.OneToMany {
+		owner, err := gen.ins.FindTable(fk.Schema, fk.Table)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mappedBy := gen.manyToOneNames(owner, gen.manyToOnes(owner))[fk.Name]
+		return []string{fmt.Sprintf(`@OneToMany(mappedBy = "%s")`, mappedBy)}
 	}
 
-	ret = append(ret, fmt.Sprintf(`@Column(%s)`, strings.Join(column_args, ", ")))
+	optional := false
+	var joins []string
+	for i, name := range fk.Columns {
+		args := []string{
+			fmt.Sprintf(`name="%s"`, name),
+			fmt.Sprintf(`referencedColumnName="%s"`, fk.RefColumns[i]),
+		}
+		for _, col := range table.Columns {
+			if col.Name == name {
+				args = append(args, fmt.Sprintf("nullable=%t", !col.NotNull))
+				optional = optional || !col.NotNull
+			}
+		}
+		if p.ReadOnly {
+			args = append(args, "insertable=false", "updatable=false")
+		}
+		joins = append(joins, fmt.Sprintf("@JoinColumn(%s)", strings.Join(args, ", ")))
+	}
 
+	var ret []string
+	if optional {
+		ret = append(ret, "@ManyToOne(fetch = FetchType.LAZY)")
+	} else {
+		ret = append(ret, "@ManyToOne(fetch = FetchType.LAZY, optional = false)")
+	}
+	if len(joins) == 1 {
+		ret = append(ret, joins[0])
+	} else {
+		ret = append(ret, fmt.Sprintf("@JoinColumns({%s})", strings.Join(joins, ", ")))
+	}
 	return ret
 }
 
-func (gen *Hibernate) setter(col Column) (string, error) {
+func (gen *Hibernate) setter(p HibernateProperty) (string, error) {
 	var ret bytes.Buffer
 	var constraint string
-	if col.Constraint.String == "c" {
-		constraint = "    // " + col.ConstraintSrc.String
-	}
-
 	var scope = "public"
-	if contains(gen.config.NotInsertableColumns, col.Name) && contains(gen.config.NotUpdatableColumns, col.Name) {
-		scope = "private"
+	if col := p.Column; col != nil {
+		if col.Constraint.String == "c" {
+			constraint = "    // " + col.ConstraintSrc.String
+		}
+		if contains(gen.config.NotInsertableColumns, col.Name) && contains(gen.config.NotUpdatableColumns, col.Name) {
+			scope = "private"
+		}
 	}
 
-	t := gen.convertType(col)
-	if col.Array {
-		t = fmt.Sprintf("%s[]", t)
-	}
 	data := map[string]interface{}{
-		"func":       SnakeToUpperCamel(col.Name),
-		"name":       SnakeToLowerCamel(col.Name),
-		"type":       t,
+		"func":       p.funcName(),
+		"name":       p.Name,
+		"type":       p.Type,
 		"scope":      scope,
 		"constraint": constraint,
 	}
 	if err := gen.template.ExecuteTemplate(&ret, "setter", data); err != nil {
-		return "", errors.Wrap(err, "setter: "+col.Name)
+		return "", errors.Wrap(err, "setter: "+p.Name)
 	}
 
 	return ret.String(), nil
 }
 
+// isInteger reports whether v is a Java Integer which String.valueOf
+// converts back to v, so "01" and "+1" are not.
+func isInteger(v string) bool {
+	n, err := strconv.ParseInt(v, 10, 32)
+	return err == nil && strconv.FormatInt(n, 10) == v
+}
+
+// buildType writes an enum to wr, and its UserType to utwr unless enums
+// are mapped by converters.
 func (gen *Hibernate) buildType(wr, utwr io.Writer, typ Type) error {
 	var mem []string
 	dt := "String"
 
+	// values are Integer only if all of them are integers
+	numeric := len(typ.Values) > 0
 	for _, val := range typ.Values {
-		if isNumber(val) {
-			mem = append(mem, fmt.Sprintf("VALUE_%s(%s)", SnakeToUpper(val), val))
-			dt = "Integer"
+		numeric = numeric && isInteger(val)
+	}
+	if numeric {
+		dt = "Integer"
+	}
+	labels := make(map[string]string)
+	for _, val := range typ.Values {
+		name := enumConstant(val)
+		if other, ok := labels[name]; ok {
+			return fmt.Errorf("labels %q and %q of %s are both named %s", other, val, typ.Name, name)
+		}
+		labels[name] = val
+		if numeric {
+			mem = append(mem, fmt.Sprintf("%s(%s)", name, val))
 		} else {
-			mem = append(mem, fmt.Sprintf(`%s("%s")`, SnakeToUpper(val), val))
+			mem = append(mem, fmt.Sprintf("%s(%s)", name, javaString(val)))
 		}
 	}
 
@@ -387,15 +1001,24 @@ func (gen *Hibernate) buildType(wr, utwr io.Writer, typ Type) error {
 		"type":         typ,
 		"dt":           dt,
 		"members":      members,
+		"converter":    gen.converter(),
+		"jpa":          gen.jpaNamespace(),
 	}); err != nil {
 		return err
 	}
+	if gen.converter() {
+		return nil
+	}
 
-	if err := gen.template.ExecuteTemplate(utwr, "enum_usertype", map[string]interface{}{
+	usertype := "enum_usertype"
+	if gen.hibernate6() {
+		usertype = "enum_usertype6"
+	}
+	if err := gen.template.ExecuteTemplate(utwr, usertype, map[string]interface{}{
 		"package_name": gen.config.PackageName,
 		"now":          time.Now().UTC().Format(time.RFC3339),
 		"name":         SnakeToUpperCamel(typ.Name),
-		"snake":        (typ.Name),
+		"snake":        qualifiedName(typ.Schema, typ.Name),
 		"type":         typ,
 		"dt":           dt,
 		"members":      members,
@@ -406,73 +1029,68 @@ func (gen *Hibernate) buildType(wr, utwr io.Writer, typ Type) error {
 	return nil
 }
 
-func (gen *Hibernate) enumExists(typeName string) bool {
-	for _, typ := range gen.ins.Types {
-		if typ.Name == typeName {
-			return true
-		}
-	}
-	return false
+// hibernateTypes maps Postgres types to Java types.
+// http://docs.jboss.org/hibernate/orm/5.2/userguide/html_single/Hibernate_User_Guide.html#basic
+var hibernateTypes = map[PgType]string{
+	PgBool:        "Boolean",
+	PgInt16:       "Short",
+	PgInt32:       "Integer",
+	PgInt64:       "Long",
+	PgFloat32:     "Float",
+	PgFloat64:     "Double",
+	PgNumeric:     "BigDecimal",
+	PgMoney:       "BigDecimal",
+	PgText:        "String",
+	PgUUID:        "UUID",
+	PgBytes:       "byte[]", // always byte[]
+	PgDate:        "LocalDate",
+	PgTime:        "LocalTime",
+	PgTimeTZ:      "OffsetTime",
+	PgTimestamp:   "Timestamp",
+	PgTimestampTZ: "OffsetDateTime",
+	PgInterval:    "String",
+	PgJSON:        "JsonObject",
+	PgXML:         "String",
+	PgNetwork:     "String",
+	PgMacAddr:     "String",
+	PgBit:         "String",
+	PgTextSearch:  "String",
+	PgGeometric:   "String",
+	PgRange:       "String",
 }
 
-func (gen *Hibernate) convertType(col Column) string {
-	// numeric with presidion is double
-	if strings.Contains(col.DataType, "numeric(") {
-		return "BigDecimal"
-	}
+// hibernateStringCasts are the types mapped to String which are not text.
+var hibernateStringCasts = map[PgType]bool{
+	PgInterval:   true,
+	PgXML:        true,
+	PgNetwork:    true,
+	PgMacAddr:    true,
+	PgBit:        true,
+	PgTextSearch: true,
+	PgGeometric:  true,
+	PgRange:      true,
+}
+
+// castString reports whether col is mapped to String but needs a cast from
+// varchar on write.
+func castString(col Column) bool {
+	pt, ok := lookupPgType(col.DataType)
+	return ok && hibernateStringCasts[pt]
+}
 
+func (gen *Hibernate) convertType(col Column) string {
 	// Remove array charactor (we can still get this column is array from other value)
 	t := strings.Replace(col.DataType, "[]", "", 1)
 
-	// http://docs.jboss.org/hibernate/orm/5.2/userguide/html_single/Hibernate_User_Guide.html#basic
-
-	switch t {
-	case "text":
-		return "String"
-	case "int", "integer":
-		return "Integer"
-	case "float":
-		return "Float"
-	case "double", "double precision":
-		return "Double"
-	case "bigint":
-		return "Long"
-	case "serial":
-		return "Integer"
-	case "bigserial":
-		return "Long"
-	case "uuid":
-		return "UUID"
-	case "bytea":
-		return "byte[]" // always byte[]
-	case "numeric":
-		return "BigDecimal"
-	case "date":
-		return "LocalDate"
-	case "json", "jsonb":
-		return "JsonObject"
-	case "timestamp":
-		return "Timestamp"
-	case "boolean":
-		return "Boolean"
-	default:
-		// "timestamp with time zone", "timestamp without time zone", "timestamp(n) with time zone"
-		if strings.HasSuffix(t, "time zone") {
-			return "OffsetDateTime"
-		}
-		if strings.HasPrefix(t, "numeric") {
-			return "BigDecimal"
-		}
-		if strings.HasPrefix(t, "character") {
-			return "String"
-		}
-
-		typ, err := gen.ins.FindType(t)
-		if err == nil {
-			return SnakeToUpperCamel(typ.Name)
-		}
+	if pt, ok := lookupPgType(t); ok {
+		return hibernateTypes[pt]
+	}
+	typ, err := gen.ins.FindType(t)
+	if err == nil {
+		return SnakeToUpperCamel(typ.Name)
 	}
-	return col.DataType
+	warnUnknownType(HibernateTypeName, col.DataType, "Object")
+	return "Object"
 }
 
 func loadHibernateConfig(root string, raw json.RawMessage) (HibernateConfig, error) {
@@ -484,5 +1102,60 @@ func loadHibernateConfig(root string, raw json.RawMessage) (HibernateConfig, err
 	if err := DirExists(output); err != nil {
 		return hc, fmt.Errorf("hibernate output is not exists: %s", hc.Output)
 	}
+	if err := hc.validate(); err != nil {
+		return hc, fmt.Errorf("hibernate %s", err)
+	}
 	return hc, nil
 }
+
+// validate checks the options and sets their defaults. Options are shared
+// with the kotlin generator.
+func (c *HibernateConfig) validate() error {
+	switch c.CompositeKeyStrategy {
+	case "":
+		c.CompositeKeyStrategy = HibernateIdClass
+	case HibernateIdClass, HibernateEmbeddedId:
+	default:
+		return fmt.Errorf("unknown composite_key_strategy: %s", c.CompositeKeyStrategy)
+	}
+	switch c.HibernateVersion {
+	case 0:
+		c.HibernateVersion = 5
+	case 5, 6:
+	default:
+		return fmt.Errorf("unknown hibernate_version: %d", c.HibernateVersion)
+	}
+	switch c.JpaNamespace {
+	case "":
+		c.JpaNamespace = HibernateJavax
+		if c.HibernateVersion == 6 {
+			c.JpaNamespace = HibernateJakarta
+		}
+	case HibernateJavax, HibernateJakarta:
+	default:
+		return fmt.Errorf("unknown jpa_namespace: %s", c.JpaNamespace)
+	}
+	if c.HibernateVersion == 6 && c.JpaNamespace != HibernateJakarta {
+		return fmt.Errorf("hibernate_version 6 requires jpa_namespace jakarta")
+	}
+	switch c.EnumMapping {
+	case "":
+		c.EnumMapping = HibernateUserType
+	case HibernateUserType, HibernateConverter:
+	default:
+		return fmt.Errorf("unknown enum_mapping: %s", c.EnumMapping)
+	}
+	switch c.AccessorStyle {
+	case "":
+		c.AccessorStyle = HibernatePlain
+	case HibernatePlain, HibernateLombok, HibernateRecord:
+	default:
+		return fmt.Errorf("unknown accessor_style: %s", c.AccessorStyle)
+	}
+	switch c.BeanValidation {
+	case "", HibernateJavax, HibernateJakarta:
+	default:
+		return fmt.Errorf("unknown bean_validation: %s", c.BeanValidation)
+	}
+	return c.TypeOverrides.validate()
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"database/sql"
+	"reflect"
+	"strings"
 	"testing"
+	"text/template"
 )
 
 func TestDecapitalize(t *testing.T) {
@@ -42,7 +46,11 @@ func TestConvertType(t *testing.T) {
 		[]string{"timestamp(3) with time zone", "OffsetDateTime"},
 		[]string{"numeric(10)", "BigDecimal"},
 		[]string{"character(10)", "String"},
-		[]string{"fooBar", "fooBar"},
+		[]string{"timestamp without time zone", "Timestamp"},
+		[]string{"time with time zone", "OffsetTime"},
+		[]string{"smallint", "Short"},
+		[]string{"inet", "String"},
+		[]string{"fooBar", "Object"},
 	}
 	for _, d := range ff {
 		col := Column{
@@ -54,3 +62,612 @@ func TestConvertType(t *testing.T) {
 	}
 
 }
+
+func relationInspectResult() InspectResult {
+	return InspectResult{
+		Tables: []Table{
+			{
+				Schema: "public",
+				Name:   "customer",
+				Columns: []Column{
+					{Name: "id", DataType: "bigint", NotNull: true, PrimaryKey: true},
+					{Name: "name", DataType: "text", NotNull: true},
+				},
+			},
+			{
+				Schema: "public",
+				Name:   "order_item",
+				Columns: []Column{
+					{Name: "id", DataType: "bigint", NotNull: true, PrimaryKey: true},
+					{Name: "customer_id", DataType: "bigint", NotNull: true},
+				},
+				ForeignKeys: []ForeignKey{
+					{
+						Name:       "order_item_customer_id_fkey",
+						Schema:     "public",
+						Table:      "order_item",
+						Columns:    []string{"customer_id"},
+						RefSchema:  "public",
+						RefTable:   "customer",
+						RefColumns: []string{"id"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestHibernateRelationships(t *testing.T) {
+	h := Hibernate{
+		config: HibernateConfig{
+			PackageName: "com.example",
+			Relationships: HibernateRelationshipConfig{
+				ManyToOne: true,
+				OneToMany: []string{"customer_id_fkey$"},
+			},
+		},
+		ins:      relationInspectResult(),
+		template: template.Must(template.ParseGlob("templates/hibernate/*.tmpl")),
+	}
+
+	var buf bytes.Buffer
+	if err := h.buildTable(&buf, h.ins.Tables[1]); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"private Customer customer;",
+		"@ManyToOne(fetch = FetchType.LAZY, optional = false)",
+		`@JoinColumn(name="customer_id", referencedColumnName="id", nullable=false)`,
+		"public Customer getCustomer()",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "customerId") {
+		t.Errorf("join column should be replaced by association\n%s", out)
+	}
+
+	buf.Reset()
+	if err := h.buildTable(&buf, h.ins.Tables[0]); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, expected := range []string{
+		"private List<OrderItem> orderItems = new ArrayList<>();",
+		`@OneToMany(mappedBy = "customer")`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestHibernateCompositeJoinColumns(t *testing.T) {
+	h := Hibernate{
+		config: HibernateConfig{
+			Relationships: HibernateRelationshipConfig{ManyToOne: true},
+		},
+		ins: InspectResult{
+			Tables: []Table{{Schema: "public", Name: "invoice"}},
+		},
+	}
+	table := Table{
+		Schema: "public",
+		Name:   "invoice_line",
+		Columns: []Column{
+			{Name: "invoice_id", DataType: "bigint", NotNull: true, PrimaryKey: true},
+			{Name: "invoice_year", DataType: "integer", NotNull: true, PrimaryKey: true},
+		},
+		ForeignKeys: []ForeignKey{
+			{
+				Name:       "invoice_line_invoice_fkey",
+				Columns:    []string{"invoice_id", "invoice_year"},
+				RefSchema:  "public",
+				RefTable:   "invoice",
+				RefColumns: []string{"id", "year"},
+			},
+		},
+	}
+	props := h.properties(table)
+	if len(props) != 3 || props[2].Name != "invoice" || !props[2].ReadOnly {
+		t.Fatalf("unexpected properties: %v", props)
+	}
+	an := h.relationAnotations(table, props[2])
+	expected := `@JoinColumns({@JoinColumn(name="invoice_id", referencedColumnName="id", nullable=false, insertable=false, updatable=false), ` +
+		`@JoinColumn(name="invoice_year", referencedColumnName="year", nullable=false, insertable=false, updatable=false)})`
+	if an[1] != expected {
+		t.Errorf("expected %s, actual: %s", expected, an[1])
+	}
+}
+
+func compositeKeyTable() Table {
+	cols := []Column{
+		{Name: "tenant_id", DataType: "bigint", NotNull: true, PrimaryKey: true},
+		{Name: "code", DataType: "text", NotNull: true, PrimaryKey: true},
+		{Name: "label", DataType: "text"},
+	}
+	return Table{
+		Schema:      "public",
+		Name:        "item",
+		Columns:     cols,
+		PrimaryKeys: []Column{cols[0], cols[1]},
+	}
+}
+
+func TestHibernateCompositeKey(t *testing.T) {
+	tmpl := template.Must(template.ParseGlob("templates/hibernate/*.tmpl"))
+	table := compositeKeyTable()
+
+	h := Hibernate{
+		config:   HibernateConfig{PackageName: "com.example", CompositeKeyStrategy: HibernateIdClass},
+		template: tmpl,
+	}
+	var buf bytes.Buffer
+	if err := h.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "@IdClass(ItemId.class)") || strings.Count(out, "@Id\n") != 2 {
+		t.Errorf("unexpected id class entity\n%s", out)
+	}
+	buf.Reset()
+	if err := h.buildIdClass(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"public class ItemId implements java.io.Serializable",
+		"Objects.equals(this.tenantId, other.tenantId)",
+		"&& Objects.equals(this.code, other.code);",
+		"return Objects.hash(this.tenantId, this.code);",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "@Embeddable") || strings.Contains(out, "@Column") {
+		t.Errorf("id class should not have mappings\n%s", out)
+	}
+
+	h.config.CompositeKeyStrategy = HibernateEmbeddedId
+	buf.Reset()
+	if err := h.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, expected := range []string{
+		"private ItemId id;",
+		"@EmbeddedId\n    public ItemId getId()",
+		"private String label;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "tenantId") || strings.Contains(out, "@IdClass") {
+		t.Errorf("primary key columns should be embedded\n%s", out)
+	}
+	buf.Reset()
+	if err := h.buildIdClass(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	if !strings.Contains(out, "@Embeddable") || !strings.Contains(out, `@Column(name="tenant_id", nullable=false)`) || strings.Contains(out, "@Id\n") {
+		t.Errorf("unexpected embeddable\n%s", out)
+	}
+}
+
+func TestHibernateTypeOverrides(t *testing.T) {
+	h := Hibernate{
+		config: HibernateConfig{
+			PackageName: "com.example",
+			TypeOverrides: TypeOverrides{
+				"inet": {
+					Type:        "InetAddress",
+					Imports:     []string{"java.net.InetAddress"},
+					Annotations: []string{"@Convert(converter = InetConverter.class)"},
+				},
+			},
+		},
+		template: template.Must(template.ParseGlob("templates/hibernate/*.tmpl")),
+	}
+	table := Table{
+		Schema: "public",
+		Name:   "host",
+		Columns: []Column{
+			{Name: "addr", DataType: "inet", NotNull: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := h.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import java.net.InetAddress;\n",
+		"private InetAddress addr;",
+		"@Convert(converter = InetConverter.class)",
+		"public InetAddress getAddr()",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestHibernateColumnSize(t *testing.T) {
+	h := Hibernate{}
+	cases := []struct {
+		dataType string
+		expected string
+	}{
+		{"character varying(255)", `@Column(name="c", nullable=true, length=255)`},
+		{"numeric(12,4)", `@Column(name="c", nullable=true, precision=12, scale=4)`},
+		{"numeric", `@Column(name="c", nullable=true)`},
+	}
+	for _, c := range cases {
+		col := Column{Name: "c", DataType: c.dataType}
+		parseDataType(&col)
+		an := h.anotations(HibernateProperty{Column: &col})
+		if actual := an[len(an)-1]; actual != c.expected {
+			t.Errorf("expected %s, actual: %s", c.expected, actual)
+		}
+	}
+}
+
+func TestHibernateStringCast(t *testing.T) {
+	h := Hibernate{}
+	cases := []struct {
+		dataType string
+		expected string
+	}{
+		{"inet", `@ColumnTransformer(write = "?::inet")`},
+		{"interval day", `@ColumnTransformer(write = "?::interval day")`},
+		{"bit varying(8)", `@ColumnTransformer(write = "?::bit varying(8)")`},
+		{"tsvector", `@ColumnTransformer(write = "?::tsvector")`},
+		{"int4range", `@ColumnTransformer(write = "?::int4range")`},
+		{"xml", `@ColumnTransformer(write = "?::xml")`},
+		{"text", ""},
+		{"character varying(20)", ""},
+	}
+	for _, c := range cases {
+		col := Column{Name: "c", DataType: c.dataType}
+		parseDataType(&col)
+		actual := ""
+		for _, a := range h.anotations(HibernateProperty{Column: &col}) {
+			if strings.HasPrefix(a, "@ColumnTransformer") {
+				actual = a
+			}
+		}
+		if actual != c.expected {
+			t.Errorf("%s: expected %s, actual: %s", c.dataType, c.expected, actual)
+		}
+	}
+}
+
+func TestHibernateValidations(t *testing.T) {
+	h := Hibernate{
+		config: HibernateConfig{
+			BeanValidation:       HibernateJakarta,
+			NotInsertableColumns: []string{"created_at"},
+		},
+	}
+	cases := []struct {
+		col      Column
+		expected []string
+	}{
+		{Column{Name: "id", DataType: "integer", NotNull: true, PrimaryKey: true, Serial: true}, nil},
+		{Column{Name: "created_at", DataType: "timestamp with time zone", NotNull: true}, nil},
+		{Column{Name: "name", DataType: "character varying(255)", NotNull: true, Checks: []string{"CHECK (char_length(name::text) >= 1)"}},
+			[]string{"@NotNull", "@Size(min=1, max=255)"}},
+		{Column{Name: "code", DataType: "text", Checks: []string{"CHECK (code ~ '^[A-Z]\\d+$'::text)", "CHECK (char_length(code) <= 20)"}},
+			[]string{"@Size(max=20)", `@Pattern(regexp="^[A-Z]\\d+$")`}},
+		{Column{Name: "tag", DataType: "text", Checks: []string{"CHECK (tag ~* 'foo'::text)"}},
+			[]string{`@Pattern(regexp="(?is).*(?:foo).*")`}},
+		{Column{Name: "qty", DataType: "integer", NotNull: true, Checks: []string{"CHECK (qty > 0 AND qty <= 100)"}},
+			[]string{"@NotNull", "@Min(1)", "@Max(100)"}},
+		{Column{Name: "price", DataType: "numeric(12,4)", Checks: []string{"CHECK (price > 0::numeric AND price <= 99.5)"}},
+			[]string{`@DecimalMin(value="0", inclusive=false)`, `@DecimalMax("99.5")`, "@Digits(integer=8, fraction=4)"}},
+		{Column{Name: "scores", DataType: "integer[]", NotNull: true, Checks: []string{"CHECK (scores >= 0)"}},
+			[]string{"@NotNull"}},
+	}
+	for _, c := range cases {
+		col := c.col
+		parseDataType(&col)
+		col.Array = col.ArrayDims > 0
+		actual := h.validations(HibernateProperty{Column: &col})
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %v, actual: %v", col.Name, c.expected, actual)
+		}
+	}
+
+	props := []HibernateProperty{{Column: &Column{Name: "name", DataType: "text", NotNull: true}}}
+	if imports := h.imports(props); !reflect.DeepEqual(imports, []string{"jakarta.validation.constraints.NotNull"}) {
+		t.Errorf("unexpected imports: %v", imports)
+	}
+}
+
+func TestHibernate6(t *testing.T) {
+	h := Hibernate{
+		config: HibernateConfig{
+			PackageName:        "com.example",
+			JpaNamespace:       HibernateJakarta,
+			HibernateVersion:   6,
+			VersionFieldColumn: "version",
+		},
+		ins: InspectResult{
+			Types: []Type{{Schema: "public", Name: "status", Values: []string{"active", "closed"}}},
+		},
+		template: template.Must(template.ParseGlob("templates/hibernate/*.tmpl")),
+	}
+	table := Table{
+		Schema: "public",
+		Name:   "account",
+		Columns: []Column{
+			{Name: "status", DataType: "status", NotNull: true},
+			{Name: "attrs", DataType: "jsonb"},
+			{Name: "tags", DataType: "text[]", Array: true},
+			{Name: "version", DataType: "integer", NotNull: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := h.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import jakarta.persistence.Entity;\n",
+		"import org.hibernate.type.SqlTypes;\n",
+		"@Type(StatusUserType.class)\n",
+		"@JdbcTypeCode(SqlTypes.JSON)\n",
+		"@JdbcTypeCode(SqlTypes.ARRAY)\n",
+		"@jakarta.persistence.Version\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "javax.") || strings.Contains(out, "@Type(type") {
+		t.Errorf("unexpected hibernate 5 mapping\n%s", out)
+	}
+
+	var enum, usertype bytes.Buffer
+	if err := h.buildType(&enum, &usertype, h.ins.Types[0]); err != nil {
+		t.Fatal(err)
+	}
+	out = usertype.String()
+	for _, expected := range []string{
+		"public class StatusUserType implements UserType<Status>",
+		"public Status nullSafeGet(\n      ResultSet rs, int position,",
+		"PreparedStatement st, Status value, int index,",
+		`pgobject.setType("status");`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestLoadHibernateConfigVersion(t *testing.T) {
+	cases := []struct {
+		raw       string
+		namespace string
+		version   int
+		err       bool
+	}{
+		{`{"output": "."}`, HibernateJavax, 5, false},
+		{`{"output": ".", "jpa_namespace": "jakarta"}`, HibernateJakarta, 5, false},
+		{`{"output": ".", "hibernate_version": 6}`, HibernateJakarta, 6, false},
+		{`{"output": ".", "hibernate_version": 6, "jpa_namespace": "javax"}`, "", 0, true},
+		{`{"output": ".", "hibernate_version": 4}`, "", 0, true},
+		{`{"output": ".", "jpa_namespace": "java"}`, "", 0, true},
+		{`{"output": ".", "enum_mapping": "converter"}`, HibernateJavax, 5, false},
+		{`{"output": ".", "enum_mapping": "ordinal"}`, "", 0, true},
+	}
+	for _, c := range cases {
+		hc, err := loadHibernateConfig(".", []byte(c.raw))
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: error expected", c.raw)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.raw, err)
+			continue
+		}
+		if hc.JpaNamespace != c.namespace || hc.HibernateVersion != c.version {
+			t.Errorf("%s: expected %s %d, actual: %s %d", c.raw, c.namespace, c.version, hc.JpaNamespace, hc.HibernateVersion)
+		}
+	}
+}
+
+func lombokTable() Table {
+	cols := []Column{
+		{Name: "id", DataType: "bigint", NotNull: true, PrimaryKey: true, Serial: true},
+		{Name: "name", DataType: "text", NotNull: true},
+		{Name: "created_at", DataType: "timestamp with time zone", NotNull: true},
+	}
+	return Table{
+		Schema:      "public",
+		Name:        "account",
+		Columns:     cols,
+		PrimaryKeys: []Column{cols[0]},
+	}
+}
+
+func TestHibernateLombok(t *testing.T) {
+	h := Hibernate{
+		config: HibernateConfig{
+			PackageName:          "com.example",
+			AccessorStyle:        HibernateLombok,
+			NotInsertableColumns: []string{"created_at"},
+			NotUpdatableColumns:  []string{"created_at"},
+		},
+		template: template.Must(template.ParseGlob("templates/hibernate/*.tmpl")),
+	}
+
+	var buf bytes.Buffer
+	if err := h.buildTable(&buf, lombokTable()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import lombok.Getter;\n",
+		"@Getter\n@Setter\n@NoArgsConstructor\n@EqualsAndHashCode(onlyExplicitlyIncluded = true)\n@SuppressWarnings",
+		"\t@EqualsAndHashCode.Include\n\t@Id\n\t@GeneratedValue(strategy=GenerationType.IDENTITY)\n\t@Column(name=\"id\", nullable=false)\n\tprivate Long id;",
+		"\t@Setter(AccessLevel.NONE)\n\t@Column(name=\"created_at\", nullable=false, insertable=false, updatable=false)\n\tprivate OffsetDateTime createdAt;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "getName()") || strings.Contains(out, "public Account()") {
+		t.Errorf("unexpected accessor\n%s", out)
+	}
+
+	h.config.CompositeKeyStrategy = HibernateEmbeddedId
+	buf.Reset()
+	if err := h.buildIdClass(&buf, compositeKeyTable()); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, expected := range []string{
+		"@Embeddable\n@Getter\n@Setter\n@NoArgsConstructor\n@EqualsAndHashCode\n@SuppressWarnings",
+		"\t@Column(name=\"tenant_id\", nullable=false)\n\tprivate Long tenantId;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "public boolean equals") {
+		t.Errorf("unexpected equals\n%s", out)
+	}
+}
+
+func TestHibernateRecord(t *testing.T) {
+	h := Hibernate{
+		config:   HibernateConfig{PackageName: "com.example", AccessorStyle: HibernateRecord},
+		template: template.Must(template.ParseGlob("templates/hibernate/*.tmpl")),
+	}
+	var buf bytes.Buffer
+	if err := h.buildRecord(&buf, lombokTable()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	expected := "public record Account(\n\tLong id,\n\tString name,\n\tOffsetDateTime createdAt\n) implements java.io.Serializable {"
+	if !strings.Contains(out, expected) {
+		t.Errorf("%q is not found in\n%s", expected, out)
+	}
+	if strings.Contains(out, "@Entity") || strings.Contains(out, "@Column") {
+		t.Errorf("unexpected mapping\n%s", out)
+	}
+}
+
+func TestHibernateEnumConverter(t *testing.T) {
+	h := Hibernate{
+		config: HibernateConfig{PackageName: "com.example", EnumMapping: HibernateConverter},
+		ins: InspectResult{
+			Types: []Type{
+				{Schema: "billing", Name: "status", Values: []string{"active", "closed"}},
+				{Schema: "public", Name: "level", Values: []string{"1", "2"}},
+			},
+		},
+		template: template.Must(template.ParseGlob("templates/hibernate/*.tmpl")),
+	}
+	table := Table{
+		Schema: "public",
+		Name:   "account",
+		Columns: []Column{
+			{Name: "status", DataType: "billing.status", NotNull: true},
+			{Name: "level", DataType: "level"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := h.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"@Convert(converter = Status.Converter.class)\n",
+		"@ColumnTransformer(write = \"?::billing.status\")\n",
+		"@Column(name=\"status\", nullable=false, columnDefinition=\"billing.status\")\n",
+		"@Convert(converter = Level.Converter.class)\n",
+		"@Column(name=\"level\", nullable=true, columnDefinition=\"level\")\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "UserType") {
+		t.Errorf("unexpected user type\n%s", out)
+	}
+
+	var enum, usertype bytes.Buffer
+	if err := h.buildType(&enum, &usertype, h.ins.Types[1]); err != nil {
+		t.Fatal(err)
+	}
+	out = enum.String()
+	for _, expected := range []string{
+		"public enum Level implements PgEnumConverter.PgEnum {",
+		"VALUE_1(1),",
+		"private final Integer value;",
+		"public String getLabel() {\n        return String.valueOf(this.value);",
+		"public static class Converter extends PgEnumConverter<Level> {",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if usertype.Len() > 0 {
+		t.Errorf("unexpected user type\n%s", usertype.String())
+	}
+
+	buf.Reset()
+	if err := h.buildEnumConverter(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "public abstract class PgEnumConverter<E extends Enum<E> & PgEnumConverter.PgEnum>"
+	if !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q is not found in\n%s", expected, buf.String())
+	}
+}
+
+func TestHibernateEnumValues(t *testing.T) {
+	h := Hibernate{
+		config:   HibernateConfig{PackageName: "com.example", EnumMapping: HibernateConverter},
+		template: template.Must(template.ParseGlob("templates/hibernate/*.tmpl")),
+	}
+	cases := []struct {
+		values   []string
+		dt       string
+		expected string
+	}{
+		{[]string{"-1", "2"}, "Integer", "VALUE_1(-1), VALUE_2(2);"},
+		{[]string{"01", "2"}, "String", `VALUE_01("01"), VALUE_2("2");`},
+		{[]string{"1.5", "2"}, "String", `VALUE_1_5("1.5"), VALUE_2("2");`},
+		{[]string{"in-review", `say "hi"`}, "String", `IN_REVIEW("in-review"), SAY_HI("say \"hi\"");`},
+	}
+	for _, c := range cases {
+		var enum, usertype bytes.Buffer
+		if err := h.buildType(&enum, &usertype, Type{Name: "level", Values: c.values}); err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{c.expected, "private final " + c.dt + " value;"} {
+			if !strings.Contains(enum.String(), expected) {
+				t.Errorf("%q is not found in\n%s", expected, enum.String())
+			}
+		}
+	}
+
+	for _, values := range [][]string{{"in-review", "in_review"}, {"-1", "1"}} {
+		var enum, usertype bytes.Buffer
+		if err := h.buildType(&enum, &usertype, Type{Name: "level", Values: values}); err == nil {
+			t.Errorf("%v: expected an error", values)
+		}
+	}
+}
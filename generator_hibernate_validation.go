@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// validations returns Bean Validation constraints of a column property,
+// derived from NOT NULL, type modifiers and CHECK constraints. Nothing is
+// returned unless bean_validation is set.
+func (gen *Hibernate) validations(p HibernateProperty) []string {
+	col := p.Column
+	if gen.config.BeanValidation == "" || col == nil {
+		return nil
+	}
+
+	var ret []string
+	// values of generated columns are given by the database
+	generated := col.Serial || isSequence(*col) || contains(gen.config.NotInsertableColumns, col.Name)
+	if col.NotNull && !generated {
+		ret = append(ret, "@NotNull")
+	}
+	if p.Override != nil || col.Array {
+		// constraints below depend on the java type
+		return ret
+	}
+
+	check := parseChecks(*col)
+	pt, _ := lookupPgType(col.BaseType)
+	switch pt {
+	case PgText:
+		max := col.Length
+		if check.MaxLength > 0 && (max == 0 || check.MaxLength < max) {
+			max = check.MaxLength
+		}
+		var args []string
+		if check.MinLength > 0 {
+			args = append(args, fmt.Sprintf("min=%d", check.MinLength))
+		}
+		if max > 0 {
+			args = append(args, fmt.Sprintf("max=%d", max))
+		}
+		if len(args) > 0 {
+			ret = append(ret, fmt.Sprintf("@Size(%s)", strings.Join(args, ", ")))
+		}
+		for _, pattern := range check.Patterns {
+			ret = append(ret, fmt.Sprintf("@Pattern(regexp=%s)", javaString(javaPattern(pattern))))
+		}
+	case PgInt16, PgInt32, PgInt64, PgFloat32, PgFloat64, PgNumeric, PgMoney:
+		integer := pt == PgInt16 || pt == PgInt32 || pt == PgInt64
+		if check.Min != "" {
+			ret = append(ret, javaBound("Min", check.Min, check.MinExclusive, integer))
+		}
+		if check.Max != "" {
+			ret = append(ret, javaBound("Max", check.Max, check.MaxExclusive, integer))
+		}
+		if col.Precision > 0 {
+			ret = append(ret, fmt.Sprintf("@Digits(integer=%d, fraction=%d)", col.Precision-col.Scale, col.Scale))
+		}
+	}
+	return ret
+}
+
+// validationImports returns the classes of the constraints used by props.
+func (gen *Hibernate) validationImports(props []HibernateProperty) []string {
+	var ret []string
+	for _, p := range props {
+		for _, v := range gen.validations(p) {
+			name := strings.TrimPrefix(strings.SplitN(v, "(", 2)[0], "@")
+			i := gen.config.BeanValidation + ".validation.constraints." + name
+			if !contains(ret, i) {
+				ret = append(ret, i)
+			}
+		}
+	}
+	return ret
+}
+
+// javaBound returns @Min or @Max of v. @DecimalMin or @DecimalMax is used if
+// v is not an integer or the bound is exclusive for a non integer column.
+func javaBound(kind, v string, exclusive, integer bool) string {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err == nil && (integer || !exclusive) {
+		if exclusive {
+			// an exclusive bound of an integer is the next integer
+			if kind == "Min" {
+				n++
+			} else {
+				n--
+			}
+		}
+		return fmt.Sprintf("@%s(%d)", kind, n)
+	}
+	if exclusive {
+		return fmt.Sprintf("@Decimal%s(value=%s, inclusive=false)", kind, javaString(v))
+	}
+	return fmt.Sprintf("@Decimal%s(%s)", kind, javaString(v))
+}
+
+// javaPattern converts p for @Pattern, which must match the whole value
+// while "~" of Postgres matches a part of it.
+func javaPattern(p CheckPattern) string {
+	re := p.Regexp
+	var flags string
+	if p.CaseInsensitive {
+		flags = "i"
+	}
+	if !strings.HasPrefix(re, "^") || !strings.HasSuffix(re, "$") || strings.HasSuffix(re, `\$`) {
+		re = ".*(?:" + re + ").*"
+		flags += "s"
+	}
+	if flags != "" {
+		re = "(?" + flags + ")" + re
+	}
+	return re
+}
+
+// javaString returns s as a Java string literal.
+func javaString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
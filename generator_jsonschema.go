@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type JSONSchemaConfig struct {
+	Output       string   `json:"output"`
+	IgnoreTables []string `json:"ignore_tables"`
+	// BaseID is prepended to the file name to make $id, such as
+	// "https://example.com/schemas/".
+	BaseID string `json:"base_id"`
+	// NoAdditionalProperties sets "additionalProperties": false on tables.
+	NoAdditionalProperties bool          `json:"no_additional_properties"`
+	TypeOverrides          TypeOverrides `json:"type_overrides"`
+}
+
+type JSONSchema struct {
+	db     *sql.DB
+	config JSONSchemaConfig
+	ins    InspectResult
+	root   string
+}
+
+// JSONSchemaNode is a (sub)schema of JSON Schema draft 2020-12.
+type JSONSchemaNode struct {
+	Schema               string                     `json:"$schema,omitempty"`
+	ID                   string                     `json:"$id,omitempty"`
+	Ref                  string                     `json:"$ref,omitempty"`
+	Title                string                     `json:"title,omitempty"`
+	Description          string                     `json:"description,omitempty"`
+	Type                 interface{}                `json:"type,omitempty"`
+	Format               string                     `json:"format,omitempty"`
+	Pattern              string                     `json:"pattern,omitempty"`
+	ContentEncoding      string                     `json:"contentEncoding,omitempty"`
+	Enum                 []string                   `json:"enum,omitempty"`
+	MaxLength            int                        `json:"maxLength,omitempty"`
+	Items                *JSONSchemaNode            `json:"items,omitempty"`
+	AnyOf                []*JSONSchemaNode          `json:"anyOf,omitempty"`
+	Properties           JSONSchemaProperties       `json:"properties,omitempty"`
+	Required             []string                   `json:"required,omitempty"`
+	AdditionalProperties *bool                      `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*JSONSchemaNode `json:"$defs,omitempty"`
+}
+
+// JSONSchemaProperties keeps properties in column order.
+type JSONSchemaProperties []JSONSchemaProperty
+
+type JSONSchemaProperty struct {
+	Name   string
+	Schema *JSONSchemaNode
+}
+
+func (ps JSONSchemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, p := range ps {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(val)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+const JSONSchemaTypeName = "jsonschema"
+
+const (
+	jsonSchemaDraft    = "https://json-schema.org/draft/2020-12/schema"
+	jsonSchemaDefsFile = "_defs.schema.json" // "_" to keep it apart from tables
+)
+
+func NewJSONSchema(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadJSONSchemaConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := JSONSchema{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *JSONSchema) GetType() string {
+	return JSONSchemaTypeName
+}
+
+func (gen *JSONSchema) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	gen.ins = ins
+
+	// Build tables
+	for _, table := range gen.ins.Tables {
+		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+			continue
+		}
+		fileName := qualifiedName(table.Schema, table.Name) + ".schema.json"
+		if fileName == jsonSchemaDefsFile {
+			return fmt.Errorf("table %s is written to %s of enum types", table.Name, jsonSchemaDefsFile)
+		}
+		file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), fileName))
+		if err != nil {
+			return errors.Wrap(err, "build create file")
+		}
+		if err := gen.write(file, gen.buildTable(table, fileName)); err != nil {
+			file.Close()
+			return errors.Wrap(err, "build write table")
+		}
+		file.Close()
+	}
+
+	// Build types
+	file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), jsonSchemaDefsFile))
+	if err != nil {
+		return errors.Wrap(err, "build create file")
+	}
+	defer file.Close()
+	if err := gen.write(file, gen.buildType(gen.ins.Types)); err != nil {
+		return errors.Wrap(err, "build write type")
+	}
+
+	return nil
+}
+
+func (gen *JSONSchema) write(wr io.Writer, node *JSONSchemaNode) error {
+	b, err := json.MarshalIndent(node, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = wr.Write(b)
+	return err
+}
+
+func (gen *JSONSchema) id(fileName string) string {
+	if gen.config.BaseID == "" {
+		return ""
+	}
+	return gen.config.BaseID + fileName
+}
+
+func (gen *JSONSchema) buildTable(table Table, fileName string) *JSONSchemaNode {
+	ret := &JSONSchemaNode{
+		Schema:      jsonSchemaDraft,
+		ID:          gen.id(fileName),
+		Title:       qualifiedName(table.Schema, table.Name),
+		Description: table.Comment.String,
+		Type:        "object",
+	}
+	for _, col := range table.Columns {
+		ret.Properties = append(ret.Properties, JSONSchemaProperty{
+			Name:   col.Name,
+			Schema: gen.property(table, col),
+		})
+		if col.NotNull {
+			ret.Required = append(ret.Required, col.Name)
+		}
+	}
+	if gen.config.NoAdditionalProperties {
+		f := false
+		ret.AdditionalProperties = &f
+	}
+	return ret
+}
+
+func (gen *JSONSchema) buildType(types []Type) *JSONSchemaNode {
+	ret := &JSONSchemaNode{
+		Schema: jsonSchemaDraft,
+		ID:     gen.id(jsonSchemaDefsFile),
+		Defs:   make(map[string]*JSONSchemaNode),
+	}
+	for _, typ := range types {
+		ret.Defs[gen.defName(typ)] = &JSONSchemaNode{
+			Title:       qualifiedName(typ.Schema, typ.Name),
+			Description: typ.Comment.String,
+			Type:        "string",
+			Enum:        typ.Values,
+		}
+	}
+	return ret
+}
+
+func (gen *JSONSchema) defName(typ Type) string {
+	return qualifiedName(typ.Schema, typ.Name)
+}
+
+// property returns the schema of col. Nullable columns also accept null.
+func (gen *JSONSchema) property(table Table, col Column) *JSONSchemaNode {
+	t := strings.Replace(col.DataType, "[]", "", 1)
+	node := gen.convertType(t)
+	if o, ok := gen.config.TypeOverrides.find(table, col); ok {
+		node = &JSONSchemaNode{}
+		if strings.Contains(o.Type, "#") {
+			node.Ref = o.Type
+		} else {
+			node.Type = o.Type
+		}
+		// keywords are checked by validateSchemaOverrides
+		keywords, _ := schemaKeywords(o.Annotations)
+		node.Format = keywords["format"]
+		node.Pattern = keywords["pattern"]
+	}
+	if col.Length > 0 && node.Type == "string" {
+		node.MaxLength = col.Length
+	}
+	if col.Array {
+		node = &JSONSchemaNode{
+			Type:  "array",
+			Items: node,
+		}
+	}
+	if !col.NotNull {
+		switch typ := node.Type.(type) {
+		case string:
+			node.Type = []string{typ, "null"}
+		case nil:
+			if node.Ref != "" {
+				node = &JSONSchemaNode{
+					AnyOf: []*JSONSchemaNode{node, {Type: "null"}},
+				}
+			}
+		}
+	}
+	node.Description = strings.Replace(col.Comment.String, "\n", " ", -1)
+	return node
+}
+
+func (gen *JSONSchema) convertType(t string) *JSONSchemaNode {
+	if pt, ok := lookupPgType(t); ok {
+		switch pt {
+		case PgInt16, PgInt32, PgInt64:
+			return &JSONSchemaNode{Type: "integer"}
+		case PgFloat32, PgFloat64, PgNumeric:
+			return &JSONSchemaNode{Type: "number"}
+		case PgBool:
+			return &JSONSchemaNode{Type: "boolean"}
+		case PgUUID:
+			return &JSONSchemaNode{Type: "string", Format: "uuid"}
+		case PgDate:
+			return &JSONSchemaNode{Type: "string", Format: "date"}
+		case PgTimeTZ:
+			return &JSONSchemaNode{Type: "string", Format: "time"}
+		case PgTimestampTZ:
+			return &JSONSchemaNode{Type: "string", Format: "date-time"}
+		case PgBytes:
+			return &JSONSchemaNode{Type: "string", ContentEncoding: "base64"}
+		case PgJSON:
+			// any JSON value
+			return &JSONSchemaNode{}
+		}
+		return &JSONSchemaNode{Type: "string"}
+	}
+
+	typ, err := gen.ins.FindType(t)
+	if err == nil {
+		return &JSONSchemaNode{Ref: jsonSchemaDefsFile + "#/$defs/" + gen.defName(typ)}
+	}
+	warnUnknownType(JSONSchemaTypeName, t, "any value")
+	return &JSONSchemaNode{}
+}
+
+func loadJSONSchemaConfig(root string, raw json.RawMessage) (JSONSchemaConfig, error) {
+	var jc JSONSchemaConfig
+	if err := json.Unmarshal(raw, &jc); err != nil {
+		return jc, fmt.Errorf("jsonschema config error: %s", err)
+	}
+	output := filePathJoinRoot(root, jc.Output)
+	if err := DirExists(output); err != nil {
+		return jc, fmt.Errorf("jsonschema output is not exists: %s", jc.Output)
+	}
+	if err := validateSchemaOverrides(jc.TypeOverrides); err != nil {
+		return jc, fmt.Errorf("jsonschema %s", err)
+	}
+	return jc, nil
+}
+
+// validateSchemaOverrides checks type_overrides of jsonschema and openapi.
+func validateSchemaOverrides(o TypeOverrides) error {
+	if err := o.validate(); err != nil {
+		return err
+	}
+	for key, v := range o {
+		if _, err := schemaKeywords(v.Annotations); err != nil {
+			return fmt.Errorf("invalid type_overrides %s: %s", key, err)
+		}
+	}
+	return nil
+}
+
+// schemaKeywords parses annotations of type_overrides of jsonschema and
+// openapi, which are keywords in the form of "format: email".
+func schemaKeywords(annotations []string) (map[string]string, error) {
+	ret := make(map[string]string)
+	for _, a := range annotations {
+		kv := strings.SplitN(a, ":", 2)
+		key := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || (key != "format" && key != "pattern") {
+			return nil, fmt.Errorf("annotation must be \"format: ...\" or \"pattern: ...\": %s", a)
+		}
+		ret[key] = strings.TrimSpace(kv[1])
+	}
+	return ret, nil
+}
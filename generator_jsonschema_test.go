@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchemaBuildTable(t *testing.T) {
+	gen := JSONSchema{
+		config: JSONSchemaConfig{BaseID: "https://example.com/schemas/"},
+		ins: InspectResult{
+			Types: []Type{{Schema: "public", Name: "order_status", Values: []string{"open", "closed"}}},
+		},
+	}
+	table := Table{
+		Schema: "public",
+		Name:   "purchase_order",
+		Columns: []Column{
+			{Name: "id", DataType: "uuid", NotNull: true},
+			{Name: "code", DataType: "character varying(20)", Length: 20, NotNull: true, Comment: sql.NullString{String: "order code", Valid: true}},
+			{Name: "status", DataType: "order_status"},
+			{Name: "ordered_at", DataType: "timestamp with time zone", NotNull: true},
+			{Name: "amounts", DataType: "numeric[]", Array: true, NotNull: true},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := gen.write(&buf, gen.buildTable(table, "purchase_order.schema.json")); err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["$id"] != "https://example.com/schemas/purchase_order.schema.json" || doc["$schema"] != jsonSchemaDraft {
+		t.Errorf("unexpected header: %v", doc)
+	}
+	out := buf.String()
+	if strings.Index(out, `"id"`) > strings.Index(out, `"code"`) {
+		t.Errorf("properties should be in column order\n%s", out)
+	}
+	props := doc["properties"].(map[string]interface{})
+	code := props["code"].(map[string]interface{})
+	if code["maxLength"] != float64(20) || code["description"] != "order code" {
+		t.Errorf("unexpected code: %v", code)
+	}
+	if id := props["id"].(map[string]interface{}); id["format"] != "uuid" {
+		t.Errorf("unexpected id: %v", id)
+	}
+	status := props["status"].(map[string]interface{})
+	anyOf := status["anyOf"].([]interface{})
+	if len(anyOf) != 2 || anyOf[0].(map[string]interface{})["$ref"] != "_defs.schema.json#/$defs/order_status" {
+		t.Errorf("unexpected status: %v", status)
+	}
+	if at := props["ordered_at"].(map[string]interface{}); at["format"] != "date-time" {
+		t.Errorf("unexpected ordered_at: %v", at)
+	}
+	if amounts := props["amounts"].(map[string]interface{}); amounts["type"] != "array" {
+		t.Errorf("unexpected amounts: %v", amounts)
+	}
+	required := doc["required"].([]interface{})
+	if len(required) != 4 {
+		t.Errorf("unexpected required: %v", required)
+	}
+}
+
+func TestJSONSchemaNullable(t *testing.T) {
+	gen := JSONSchema{}
+	node := gen.property(Table{Name: "memo"}, Column{Name: "note", DataType: "text"})
+	if typ, ok := node.Type.([]string); !ok || typ[0] != "string" || typ[1] != "null" {
+		t.Errorf("unexpected type: %v", node.Type)
+	}
+}
+
+func TestJSONSchemaTimeFormat(t *testing.T) {
+	gen := JSONSchema{}
+	ff := [][]string{
+		{"timestamp with time zone", "date-time"},
+		{"timestamp without time zone", ""},
+		{"time with time zone", "time"},
+		{"time without time zone", ""},
+		{"interval", ""},
+		{"date", "date"},
+	}
+	for _, d := range ff {
+		if node := gen.convertType(d[0]); node.Format != d[1] {
+			t.Errorf("%s: expected %q, actual: %q", d[0], d[1], node.Format)
+		}
+	}
+}
+
+func TestJSONSchemaDefsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg2any")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	gen := JSONSchema{config: JSONSchemaConfig{Output: dir}}
+	ins := InspectResult{Tables: []Table{{Schema: "public", Name: "defs"}}}
+	if err := gen.Build(ins); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"defs.schema.json", jsonSchemaDefsFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	ins.Tables[0].Name = "_defs"
+	if err := gen.Build(ins); err == nil {
+		t.Error("table _defs should be an error")
+	}
+}
+
+func TestJSONSchemaTypeOverrides(t *testing.T) {
+	gen := JSONSchema{
+		config: JSONSchemaConfig{
+			TypeOverrides: TypeOverrides{
+				"citext":      {Type: "string", Annotations: []string{"format: email"}},
+				"user.avatar": {Type: "common.schema.json#/$defs/image"},
+			},
+		},
+	}
+	table := Table{Schema: "public", Name: "user"}
+	node := gen.property(table, Column{Name: "email", DataType: "citext", NotNull: true})
+	if node.Type != "string" || node.Format != "email" {
+		t.Errorf("unexpected schema: %+v", node)
+	}
+	node = gen.property(table, Column{Name: "avatar", DataType: "bytea"})
+	if len(node.AnyOf) != 2 || node.AnyOf[0].Ref != "common.schema.json#/$defs/image" {
+		t.Errorf("unexpected schema: %+v", node)
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// KotlinConfig has the same options as HibernateConfig, except
+// accessor_style and generate_metamodel. Enums are always mapped by
+// converters.
+type KotlinConfig struct {
+	HibernateConfig
+}
+
+type Kotlin struct {
+	db       *sql.DB
+	config   KotlinConfig
+	ins      InspectResult
+	template *template.Template
+	root     string
+
+	// hibernate maps columns and associations the same way as the hibernate
+	// generator.
+	hibernate *Hibernate
+}
+
+type KotlinProperty struct {
+	Name       string
+	Type       string
+	Comment    string
+	Init       string // initial value, empty if lateinit
+	Anotations []string
+}
+
+const KotlinTypeName = "kotlin"
+
+func NewKotlin(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadKotlinConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := Kotlin{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *Kotlin) GetType() string {
+	return KotlinTypeName
+}
+
+func (gen *Kotlin) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	log.Printf("templates: %s", filePathJoinRoot(gen.root, gen.config.Templates))
+	gen.ins = ins
+	gen.hibernate = &Hibernate{
+		config: gen.config.HibernateConfig,
+		ins:    ins,
+	}
+
+	// Load templates
+	tdir := filepath.Join(filePathJoinRoot(gen.root, gen.config.Templates), "*.tmpl")
+	t := template.Must(template.ParseGlob(tdir))
+	gen.template = t
+
+	out := &regionWriter{
+		dir:        filePathJoinRoot(gen.root, gen.config.Output),
+		overwrites: gen.config.Overwrites,
+	}
+	if err := checkNames(gen.ins, gen.config.IgnoreTables, SnakeToUpperCamel); err != nil {
+		return errors.Wrap(err, "build")
+	}
+
+	// Build tables
+	for _, table := range gen.ins.Tables {
+		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := gen.buildTable(&buf, table); err != nil {
+			return errors.Wrap(err, "build write table")
+		}
+		fileName := SnakeToUpperCamel(table.Name) + ".kt"
+		if _, err := out.write(fileName, buf.Bytes()); err != nil {
+			return errors.Wrap(err, "build write file")
+		}
+
+		if compositeKey(table) {
+			buf.Reset()
+			if err := gen.buildIdClass(&buf, table); err != nil {
+				return errors.Wrap(err, "build write id class")
+			}
+			idFileName := gen.hibernate.idClassName(table) + ".kt"
+			if _, err := out.write(idFileName, buf.Bytes()); err != nil {
+				return errors.Wrap(err, "build write id class file")
+			}
+		}
+	}
+
+	// Build types
+	for _, typ := range gen.ins.Types {
+		var buf bytes.Buffer
+		if err := gen.buildType(&buf, typ); err != nil {
+			return errors.Wrap(err, "build write type")
+		}
+		fileName := SnakeToUpperCamel(typ.Name) + ".kt"
+		if _, err := out.write(fileName, buf.Bytes()); err != nil {
+			return errors.Wrap(err, "build write file")
+		}
+	}
+
+	return out.err()
+}
+
+func (gen *Kotlin) buildTable(wr io.Writer, table Table) error {
+	props := gen.hibernate.properties(table)
+	var members []KotlinProperty
+	for _, p := range props {
+		members = append(members, gen.property(table, p))
+	}
+	return gen.template.ExecuteTemplate(wr, "class", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+		"table":        table,
+		"name":         SnakeToUpperCamel(table.Name),
+		"member":       members,
+		"id_class":     gen.hibernate.idClassAnnotation(table),
+		"unique":       gen.uniqueConstraints(table),
+		"imports":      gen.hibernate.imports(props),
+		"jpa":          gen.hibernate.jpaNamespace(),
+		"hibernate6":   gen.hibernate.hibernate6(),
+	})
+}
+
+// buildIdClass writes the primary key class as a data class, whose
+// properties have default values for the no-arg constructor of JPA.
+func (gen *Kotlin) buildIdClass(wr io.Writer, table Table) error {
+	props := gen.hibernate.idProperties(table)
+	var members []KotlinProperty
+	for _, p := range props {
+		var anotations []string
+		for _, a := range gen.hibernate.idAnotations(table, p) {
+			anotations = append(anotations, kotlinAnotation(a))
+		}
+		members = append(members, KotlinProperty{
+			Name:       p.Name,
+			Type:       gen.propertyType(p) + "?",
+			Comment:    p.Comment,
+			Init:       "null",
+			Anotations: anotations,
+		})
+	}
+
+	return gen.template.ExecuteTemplate(wr, "id_class", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+		"table":        table,
+		"entity":       SnakeToUpperCamel(table.Name),
+		"name":         gen.hibernate.idClassName(table),
+		"embeddable":   gen.hibernate.embeddedId(table),
+		"member":       members,
+		"imports":      gen.hibernate.imports(props),
+		"jpa":          gen.hibernate.jpaNamespace(),
+		"hibernate6":   gen.hibernate.hibernate6(),
+	})
+}
+
+// buildType writes an enum class which has its AttributeConverter.
+func (gen *Kotlin) buildType(wr io.Writer, typ Type) error {
+	var values []string
+	for _, val := range typ.Values {
+		values = append(values, fmt.Sprintf(`%s(%s)`, enumConstant(val), kotlinString(val)))
+	}
+
+	return gen.template.ExecuteTemplate(wr, "enum", map[string]interface{}{
+		"package_name": gen.config.PackageName,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+		"name":         SnakeToUpperCamel(typ.Name),
+		"type":         typ,
+		"values":       values,
+		"jpa":          gen.hibernate.jpaNamespace(),
+	})
+}
+
+// property converts a property of the hibernate generator.
+func (gen *Kotlin) property(table Table, p HibernateProperty) KotlinProperty {
+	ret := KotlinProperty{
+		Name:    p.Name,
+		Type:    gen.propertyType(p),
+		Comment: p.Comment,
+	}
+	for _, a := range gen.hibernate.propertyAnotations(table, p) {
+		ret.Anotations = append(ret.Anotations, kotlinAnotation(a))
+	}
+
+	switch {
+	case p.OneToMany:
+		ret.Init = "mutableListOf()"
+	case p.EmbeddedId:
+		ret.Init = ret.Type + "()"
+	case p.ForeignKey != nil:
+		if optionalForeignKey(table, *p.ForeignKey) {
+			ret.Type += "?"
+			ret.Init = "null"
+		}
+	case p.Override != nil:
+		// the type of type_overrides is used as is, primitive types can not
+		// be lateinit
+		if strings.HasSuffix(ret.Type, "?") {
+			ret.Init = "null"
+		} else {
+			ret.Init = kotlinZeroValues[ret.Type]
+		}
+	case gen.nullable(*p.Column):
+		ret.Type += "?"
+		ret.Init = "null"
+	default:
+		ret.Init = kotlinZeroValues[ret.Type]
+	}
+	return ret
+}
+
+// optionalForeignKey reports whether a join column of fk is nullable.
+func optionalForeignKey(table Table, fk ForeignKey) bool {
+	for _, col := range table.Columns {
+		if contains(fk.Columns, col.Name) && !col.NotNull {
+			return true
+		}
+	}
+	return false
+}
+
+// nullable reports whether the property of col is nullable. Values of
+// generated columns are not known until the entity is persisted.
+func (gen *Kotlin) nullable(col Column) bool {
+	return !col.NotNull || col.Serial || isSequence(col) || contains(gen.config.NotInsertableColumns, col.Name)
+}
+
+// propertyType returns the kotlin type of p.
+func (gen *Kotlin) propertyType(p HibernateProperty) string {
+	if p.Override != nil {
+		return p.Type
+	}
+	return kotlinType(p.Type)
+}
+
+// kotlinTypes maps java types of the hibernate generator to kotlin types.
+var kotlinTypes = map[string]string{
+	"Integer": "Int",
+	"byte[]":  "ByteArray",
+	"Object":  "Any",
+}
+
+// kotlinZeroValues are the initial values of NOT NULL properties of
+// primitive types, which cannot be lateinit.
+var kotlinZeroValues = map[string]string{
+	"Boolean": "false",
+	"Short":   "0",
+	"Int":     "0",
+	"Long":    "0",
+	"Float":   "0f",
+	"Double":  "0.0",
+}
+
+func kotlinType(t string) string {
+	if strings.HasPrefix(t, "List<") {
+		return "Mutable" + t
+	}
+	if k, ok := kotlinTypes[t]; ok {
+		return k
+	}
+	if strings.HasSuffix(t, "[]") {
+		return fmt.Sprintf("Array<%s>", kotlinType(strings.TrimSuffix(t, "[]")))
+	}
+	return t
+}
+
+var regJavaClassLiteral = regexp.MustCompile(`\b(\w+)\.class\b`)
+
+// kotlinAnotation converts a java annotation to kotlin. Class literals
+// become "::class", nested annotations lose "@" and arrays use brackets.
+func kotlinAnotation(a string) string {
+	a = regJavaClassLiteral.ReplaceAllString(a, "$1::class")
+	if strings.HasPrefix(a, "@JoinColumns({") {
+		inner := strings.TrimSuffix(strings.TrimPrefix(a, "@JoinColumns({"), "})")
+		inner = strings.Replace(inner, "@JoinColumn(", "JoinColumn(", -1)
+		a = "@JoinColumns(" + inner + ")"
+	}
+	// "$" starts a string template
+	return strings.Replace(a, "$", `\$`, -1)
+}
+
+// kotlinString returns s as a kotlin string literal.
+func kotlinString(s string) string {
+	return strings.Replace(javaString(s), "$", `\$`, -1)
+}
+
+// uniqueConstraints returns the arguments of @UniqueConstraint of table.
+func (gen *Kotlin) uniqueConstraints(table Table) []string {
+	var ret []string
+	for _, index := range table.Indexs {
+		var names []string
+		for _, col := range index.Columns {
+			names = append(names, kotlinString(col.Name))
+		}
+		ret = append(ret, fmt.Sprintf("UniqueConstraint(columnNames = [%s])", strings.Join(names, ", ")))
+	}
+	return ret
+}
+
+func loadKotlinConfig(root string, raw json.RawMessage) (KotlinConfig, error) {
+	var kc KotlinConfig
+	if err := json.Unmarshal(raw, &kc); err != nil {
+		return kc, fmt.Errorf("kotlin config error: %s", err)
+	}
+	output := filePathJoinRoot(root, kc.Output)
+	if err := DirExists(output); err != nil {
+		return kc, fmt.Errorf("kotlin output is not exists: %s", kc.Output)
+	}
+	if kc.EnumMapping == "" {
+		kc.EnumMapping = HibernateConverter
+	}
+	if err := kc.validate(); err != nil {
+		return kc, fmt.Errorf("kotlin %s", err)
+	}
+	if kc.AccessorStyle != HibernatePlain {
+		return kc, fmt.Errorf("kotlin accessor_style is not supported")
+	}
+	if kc.EnumMapping != HibernateConverter {
+		return kc, fmt.Errorf("kotlin enum_mapping %s is not supported", kc.EnumMapping)
+	}
+	if kc.GenerateMetamodel {
+		return kc, fmt.Errorf("kotlin generate_metamodel is not supported")
+	}
+	return kc, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func newTestKotlin(config HibernateConfig, ins InspectResult) *Kotlin {
+	config.EnumMapping = HibernateConverter
+	config.validate()
+	return &Kotlin{
+		config:    KotlinConfig{config},
+		ins:       ins,
+		template:  template.Must(template.ParseGlob("templates/kotlin/*.tmpl")),
+		hibernate: &Hibernate{config: config, ins: ins},
+	}
+}
+
+func TestKotlinType(t *testing.T) {
+	ff := [][]string{
+		{"Integer", "Int"},
+		{"Long", "Long"},
+		{"byte[]", "ByteArray"},
+		{"Integer[]", "Array<Int>"},
+		{"List<Order>", "MutableList<Order>"},
+		{"OffsetDateTime", "OffsetDateTime"},
+	}
+	for _, d := range ff {
+		if actual := kotlinType(d[0]); actual != d[1] {
+			t.Errorf("expected %s, actual: %s", d[1], actual)
+		}
+	}
+}
+
+func TestKotlinAnotation(t *testing.T) {
+	ff := [][]string{
+		{"@Type(StatusUserType.class)", "@Type(StatusUserType::class)"},
+		{`@JoinColumns({@JoinColumn(name="a", referencedColumnName="x"), @JoinColumn(name="b", referencedColumnName="y")})`,
+			`@JoinColumns(JoinColumn(name="a", referencedColumnName="x"), JoinColumn(name="b", referencedColumnName="y"))`},
+		{`@Pattern(regexp="^[a-z]+$")`, `@Pattern(regexp="^[a-z]+\$")`},
+	}
+	for _, d := range ff {
+		if actual := kotlinAnotation(d[0]); actual != d[1] {
+			t.Errorf("expected %s, actual: %s", d[1], actual)
+		}
+	}
+}
+
+func TestKotlinTable(t *testing.T) {
+	ins := InspectResult{
+		Types: []Type{{Schema: "billing", Name: "status", Values: []string{"active", "closed"}}},
+	}
+	gen := newTestKotlin(HibernateConfig{
+		PackageName:          "com.example",
+		NotInsertableColumns: []string{"created_at"},
+	}, ins)
+	cols := []Column{
+		{Name: "id", DataType: "bigint", NotNull: true, PrimaryKey: true, Serial: true},
+		{Name: "name", DataType: "character varying(20)", NotNull: true, Comment: sql.NullString{String: "account name", Valid: true}},
+		{Name: "qty", DataType: "integer", NotNull: true},
+		{Name: "note", DataType: "text"},
+		{Name: "status", DataType: "billing.status", NotNull: true},
+		{Name: "created_at", DataType: "timestamp with time zone", NotNull: true},
+	}
+	for i := range cols {
+		parseDataType(&cols[i])
+	}
+	table := Table{Schema: "billing", Name: "account", Columns: cols, PrimaryKeys: cols[:1]}
+
+	var buf bytes.Buffer
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import javax.persistence.Entity\n",
+		"@Table(\n    name = \"account\",\n    schema = \"billing\",\n)\nclass Account : Serializable {",
+		"    @Id\n    @GeneratedValue(strategy=GenerationType.IDENTITY)\n    @Column(name=\"id\", nullable=false)\n    var id: Long? = null\n",
+		"    /** account name */\n    @Column(name=\"name\", nullable=false, length=20)\n    lateinit var name: String\n",
+		"    var qty: Int = 0\n",
+		"    var note: String? = null\n",
+		"    @Convert(converter = Status.Converter::class)\n    @ColumnTransformer(write = \"?::billing.status\")\n",
+		"    @Column(name=\"status\", nullable=false, columnDefinition=\"billing.status\")\n    lateinit var status: Status\n",
+		"    var createdAt: OffsetDateTime? = null\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "UserType") {
+		t.Errorf("unexpected user type\n%s", out)
+	}
+
+	buf.Reset()
+	if err := gen.buildType(&buf, ins.Types[0]); err != nil {
+		t.Fatal(err)
+	}
+	out = buf.String()
+	for _, expected := range []string{
+		"enum class Status(val value: String) {\n    ACTIVE(\"active\"),\n    CLOSED(\"closed\");",
+		"    @javax.persistence.Converter\n    class Converter : AttributeConverter<Status, String> {",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestKotlinIdClass(t *testing.T) {
+	gen := newTestKotlin(HibernateConfig{
+		PackageName:          "com.example",
+		CompositeKeyStrategy: HibernateEmbeddedId,
+	}, InspectResult{})
+	table := compositeKeyTable()
+
+	var buf bytes.Buffer
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "    @EmbeddedId\n    var id: ItemId = ItemId()\n") {
+		t.Errorf("unexpected embedded id\n%s", out)
+	}
+
+	buf.Reset()
+	if err := gen.buildIdClass(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	expected := "@Embeddable\ndata class ItemId(\n    @Column(name=\"tenant_id\", nullable=false)\n    var tenantId: Long? = null,\n    @Column(name=\"code\", nullable=false)\n    var code: String? = null\n) : Serializable {\n"
+	if out := buf.String(); !strings.Contains(out, expected) {
+		t.Errorf("%q is not found in\n%s", expected, out)
+	}
+}
+
+func TestKotlinTypeOverrides(t *testing.T) {
+	gen := newTestKotlin(HibernateConfig{
+		PackageName: "com.example",
+		TypeOverrides: TypeOverrides{
+			"account.points": {Type: "Long"},
+			"account.code":   {Type: "Code"},
+			"account.memo":   {Type: "String?"},
+		},
+	}, InspectResult{})
+	table := Table{
+		Schema: "public",
+		Name:   "account",
+		Columns: []Column{
+			{Name: "points", DataType: "numeric", NotNull: true},
+			{Name: "code", DataType: "text", NotNull: true},
+			{Name: "memo", DataType: "text"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"    var points: Long = 0\n",
+		"    lateinit var code: Code\n",
+		"    var memo: String? = null\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type MarkdownConfig struct {
+	Output       string   `json:"output"`
+	Templates    string   `json:"templates"`
+	IgnoreTables []string `json:"ignore_tables"`
+
+	TypeOverrides TypeOverrides `json:"type_overrides"`
+}
+
+type Markdown struct {
+	db       *sql.DB
+	config   MarkdownConfig
+	ins      InspectResult
+	template *template.Template
+	root     string
+}
+
+// MarkdownLink is an entry of the index page.
+type MarkdownLink struct {
+	Name    string
+	Path    string
+	Comment string
+}
+
+// MarkdownIndexSchema groups the links of the index page by schema.
+type MarkdownIndexSchema struct {
+	Schema string
+	Tables []MarkdownLink
+	Enum   string
+}
+
+const MarkdownTypeName = "markdown"
+
+func NewMarkdown(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadMarkdownConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := Markdown{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *Markdown) GetType() string {
+	return MarkdownTypeName
+}
+
+func (gen *Markdown) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	log.Printf("templates: %s", filePathJoinRoot(gen.root, gen.config.Templates))
+	gen.ins = ins
+
+	// Load templates
+	funcs := template.FuncMap{
+		"cell": markdownCell,
+	}
+	tdir := filepath.Join(filePathJoinRoot(gen.root, gen.config.Templates), "*.tmpl")
+	t := template.Must(template.New("").Funcs(funcs).ParseGlob(tdir))
+	gen.template = t
+
+	output := filePathJoinRoot(gen.root, gen.config.Output)
+
+	// Build tables
+	for _, table := range gen.ins.Tables {
+		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+			continue
+		}
+		file, err := createFile(output, filepath.FromSlash(gen.tablePath(table)))
+		if err != nil {
+			return errors.Wrap(err, "build create file")
+		}
+		if err := gen.buildTable(file, table); err != nil {
+			file.Close()
+			return errors.Wrap(err, "build write table")
+		}
+		file.Close()
+	}
+
+	// Build types, one enum page per schema
+	schemas, types := typesBySchema(gen.ins.Types)
+	for _, schema := range schemas {
+		file, err := createFile(output, filepath.FromSlash(gen.enumPath(schema)))
+		if err != nil {
+			return errors.Wrap(err, "build create file")
+		}
+		if err := gen.buildType(file, schema, types[schema]); err != nil {
+			file.Close()
+			return errors.Wrap(err, "build write type")
+		}
+		file.Close()
+	}
+
+	// Build index
+	file, err := createFile(output, "index.md")
+	if err != nil {
+		return errors.Wrap(err, "build create file")
+	}
+	defer file.Close()
+	if err := gen.buildIndex(file); err != nil {
+		return errors.Wrap(err, "build write index")
+	}
+
+	return nil
+}
+
+// tablePath returns the slash separated path of the table page relative to
+// the output directory.
+func (gen *Markdown) tablePath(table Table) string {
+	return path.Join(filepath.ToSlash(schemaDir(table.Schema)), SnakeToUpperCamel(table.Name)+".md")
+}
+
+func (gen *Markdown) enumPath(schema string) string {
+	return path.Join(filepath.ToSlash(schemaDir(schema)), "enum.md")
+}
+
+func (gen *Markdown) buildTable(wr io.Writer, table Table) error {
+	return gen.template.ExecuteTemplate(wr, "table", map[string]interface{}{
+		"now":     time.Now().UTC().Format(time.RFC3339),
+		"comment": table.Comment.String,
+		"schema":  table.Schema,
+		"name":    table.Name,
+		"member":  docMembers(table, gen.config.TypeOverrides),
+		"index":   gen.indexPath(table.Schema),
+	})
+}
+
+func (gen *Markdown) buildType(wr io.Writer, schema string, types []Type) error {
+	var members []SphinxTypeMember
+	for _, typ := range types {
+		members = append(members, SphinxTypeMember{
+			Name:    typ.Name,
+			Comment: typ.Comment.String,
+			Values:  typ.Values,
+		})
+	}
+
+	return gen.template.ExecuteTemplate(wr, "enum", map[string]interface{}{
+		"now":     time.Now().UTC().Format(time.RFC3339),
+		"schema":  schema,
+		"members": members,
+		"index":   gen.indexPath(schema),
+	})
+}
+
+func (gen *Markdown) buildIndex(wr io.Writer) error {
+	var schemas []MarkdownIndexSchema
+	find := func(schema string) *MarkdownIndexSchema {
+		for i := range schemas {
+			if schemas[i].Schema == schema {
+				return &schemas[i]
+			}
+		}
+		schemas = append(schemas, MarkdownIndexSchema{Schema: schema})
+		return &schemas[len(schemas)-1]
+	}
+
+	for _, table := range gen.ins.Tables {
+		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+			continue
+		}
+		s := find(table.Schema)
+		s.Tables = append(s.Tables, MarkdownLink{
+			Name:    table.Name,
+			Path:    gen.tablePath(table),
+			Comment: table.Comment.String,
+		})
+	}
+	typeSchemas, _ := typesBySchema(gen.ins.Types)
+	for _, schema := range typeSchemas {
+		find(schema).Enum = gen.enumPath(schema)
+	}
+
+	return gen.template.ExecuteTemplate(wr, "index", map[string]interface{}{
+		"now":     time.Now().UTC().Format(time.RFC3339),
+		"schemas": schemas,
+	})
+}
+
+// indexPath returns the path of index.md relative to a page of the schema.
+func (gen *Markdown) indexPath(schema string) string {
+	if schemaDir(schema) == "" {
+		return "index.md"
+	}
+	return "../index.md"
+}
+
+// markdownCell escapes s to be placed in a cell of a table.
+func markdownCell(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	s = strings.Replace(s, "\r\n", "<br>", -1)
+	return strings.Replace(s, "\n", "<br>", -1)
+}
+
+func loadMarkdownConfig(root string, raw json.RawMessage) (MarkdownConfig, error) {
+	var mc MarkdownConfig
+	if err := json.Unmarshal(raw, &mc); err != nil {
+		return mc, fmt.Errorf("markdown config error: %s", err)
+	}
+	output := filePathJoinRoot(root, mc.Output)
+	if err := DirExists(output); err != nil {
+		return mc, fmt.Errorf("markdown output is not exists: %s", mc.Output)
+	}
+	if err := mc.TypeOverrides.validate(); err != nil {
+		return mc, fmt.Errorf("markdown %s", err)
+	}
+	return mc, nil
+}
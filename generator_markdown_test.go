@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func newTestMarkdown() Markdown {
+	ins := relationInspectResult()
+	ins.Tables[0].Comment = sql.NullString{String: "customer | master", Valid: true}
+	ins.Tables = append(ins.Tables, Table{
+		Schema:  "audit",
+		Name:    "log",
+		Columns: []Column{{Name: "id", DataType: "bigint", PrimaryKey: true, NotNull: true}},
+	})
+	ins.Types = []Type{
+		{Schema: "public", Name: "status", Values: []string{"active", "closed"}},
+	}
+	funcs := template.FuncMap{
+		"cell": markdownCell,
+	}
+	return Markdown{
+		ins:      ins,
+		template: template.Must(template.New("").Funcs(funcs).ParseGlob("templates/markdown/*.tmpl")),
+	}
+}
+
+func TestMarkdownTable(t *testing.T) {
+	gen := newTestMarkdown()
+	table := gen.ins.Tables[1]
+	table.Columns = append(table.Columns, Column{
+		Name:         "note",
+		DataType:     "text",
+		DefaultValue: sql.NullString{String: "'a|b'::text", Valid: true},
+		Comment:      sql.NullString{String: "multi\nline", Valid: true},
+	})
+	code := Column{Name: "code", DataType: "character varying(32)", NotNull: true}
+	parseDataType(&code)
+	table.Columns = append(table.Columns, code)
+	var buf bytes.Buffer
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"# order_item\n",
+		"| Name | Type | Size | Nullable | Default | Constraint | Comment |\n",
+		"| note | text |  | YES | `'a\\|b'::text` |  | multiline |\n",
+		"| code | character varying | 32 | NO |  |  |  |\n",
+		"[Back to index](index.md)",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	buf.Reset()
+	if err := gen.buildTable(&buf, gen.ins.Tables[2]); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "| id | bigint |  | NO |  |") || !strings.Contains(out, "(../index.md)") {
+		t.Errorf("unexpected table\n%s", out)
+	}
+}
+
+func TestMarkdownIndex(t *testing.T) {
+	gen := newTestMarkdown()
+	var buf bytes.Buffer
+	if err := gen.buildIndex(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"## public\n",
+		"| [customer](Customer.md) | customer \\| master |\n",
+		"| [order_item](OrderItem.md) |  |\n",
+		"- [Type List](enum.md)\n",
+		"## audit\n",
+		"| [log](audit/Log.md) |  |\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected string
+	}{
+		{"abc", "abc"},
+		{"a|b", `a\|b`},
+		{"a\nb", "a<br>b"},
+		{"a\r\nb", "a<br>b"},
+	}
+	for _, c := range cases {
+		if actual := markdownCell(c.in); actual != c.expected {
+			t.Errorf("expected %s, actual: %s", c.expected, actual)
+		}
+	}
+}
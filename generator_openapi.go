@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type OpenAPIConfig struct {
+	Output       string   `json:"output"`
+	IgnoreTables []string `json:"ignore_tables"`
+	// FileName is the output file name. Default is "components.yaml", or
+	// "components.json" when Format is json.
+	FileName string `json:"file_name"`
+	// Format is "yaml" (default) or "json".
+	Format  string `json:"format"`
+	Title   string `json:"title"`
+	Version string `json:"version"`
+	// CreateVariants adds a "FooCreate" schema per table which omits serial
+	// and default-valued columns.
+	CreateVariants bool          `json:"create_variants"`
+	TypeOverrides  TypeOverrides `json:"type_overrides"`
+}
+
+type OpenAPI struct {
+	db     *sql.DB
+	config OpenAPIConfig
+	ins    InspectResult
+	root   string
+}
+
+// OpenAPISchema is a Schema Object of OpenAPI 3.0.
+type OpenAPISchema struct {
+	Ref         string            `json:"$ref,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	Format      string            `json:"format,omitempty"`
+	Pattern     string            `json:"pattern,omitempty"`
+	Enum        []string          `json:"enum,omitempty"`
+	MaxLength   int               `json:"maxLength,omitempty"`
+	Nullable    bool              `json:"nullable,omitempty"`
+	Items       *OpenAPISchema    `json:"items,omitempty"`
+	AllOf       []*OpenAPISchema  `json:"allOf,omitempty"`
+	Properties  OpenAPIProperties `json:"properties,omitempty"`
+	Required    []string          `json:"required,omitempty"`
+}
+
+// OpenAPIProperties keeps properties (and schemas) in order.
+type OpenAPIProperties []OpenAPIProperty
+
+type OpenAPIProperty struct {
+	Name   string
+	Schema *OpenAPISchema
+}
+
+func (ps OpenAPIProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, p := range ps {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(val)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+type OpenAPIDocument struct {
+	OpenAPI    string            `json:"openapi"`
+	Info       OpenAPIInfo       `json:"info"`
+	Paths      struct{}          `json:"paths"`
+	Components OpenAPIComponents `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type OpenAPIComponents struct {
+	Schemas OpenAPIProperties `json:"schemas"`
+}
+
+const OpenAPITypeName = "openapi"
+
+func NewOpenAPI(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadOpenAPIConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := OpenAPI{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *OpenAPI) GetType() string {
+	return OpenAPITypeName
+}
+
+func (gen *OpenAPI) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	gen.ins = ins
+
+	file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), gen.config.FileName))
+	if err != nil {
+		return errors.Wrap(err, "build create file")
+	}
+	defer file.Close()
+	if err := gen.write(file, gen.buildDocument()); err != nil {
+		return errors.Wrap(err, "build write components")
+	}
+	return nil
+}
+
+func (gen *OpenAPI) write(wr io.Writer, doc OpenAPIDocument) error {
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if gen.config.Format == "yaml" {
+		b, err = jsonToYAML(b)
+		if err != nil {
+			return err
+		}
+	} else {
+		b = append(b, '\n')
+	}
+	_, err = wr.Write(b)
+	return err
+}
+
+func (gen *OpenAPI) buildDocument() OpenAPIDocument {
+	doc := OpenAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: OpenAPIInfo{
+			Title:   gen.config.Title,
+			Version: gen.config.Version,
+		},
+	}
+	for _, table := range gen.ins.Tables {
+		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+			continue
+		}
+		doc.Components.Schemas = append(doc.Components.Schemas, OpenAPIProperty{
+			Name:   gen.schemaName(table.Schema, table.Name),
+			Schema: gen.buildTable(table, false),
+		})
+		if gen.config.CreateVariants {
+			doc.Components.Schemas = append(doc.Components.Schemas, OpenAPIProperty{
+				Name:   gen.schemaName(table.Schema, table.Name) + "Create",
+				Schema: gen.buildTable(table, true),
+			})
+		}
+	}
+	for _, typ := range gen.ins.Types {
+		doc.Components.Schemas = append(doc.Components.Schemas, OpenAPIProperty{
+			Name:   gen.schemaName(typ.Schema, typ.Name),
+			Schema: gen.buildType(typ),
+		})
+	}
+	return doc
+}
+
+// schemaName returns the component name. Names of a schema other than the
+// default one are prefixed by the schema name.
+func (gen *OpenAPI) schemaName(schema, name string) string {
+	return SnakeToUpperCamel(strings.Replace(qualifiedName(schema, name), ".", "_", -1))
+}
+
+// generatedColumn reports whether the database fills col when it is
+// omitted on insert.
+func generatedColumn(col Column) bool {
+	return col.Serial || col.DefaultValue.String != ""
+}
+
+func (gen *OpenAPI) buildTable(table Table, create bool) *OpenAPISchema {
+	ret := &OpenAPISchema{
+		Title:       qualifiedName(table.Schema, table.Name),
+		Description: table.Comment.String,
+		Type:        "object",
+	}
+	for _, col := range table.Columns {
+		if create && generatedColumn(col) {
+			continue
+		}
+		ret.Properties = append(ret.Properties, OpenAPIProperty{
+			Name:   col.Name,
+			Schema: gen.property(table, col),
+		})
+		if col.NotNull {
+			ret.Required = append(ret.Required, col.Name)
+		}
+	}
+	return ret
+}
+
+func (gen *OpenAPI) buildType(typ Type) *OpenAPISchema {
+	return &OpenAPISchema{
+		Title:       qualifiedName(typ.Schema, typ.Name),
+		Description: typ.Comment.String,
+		Type:        "string",
+		Enum:        typ.Values,
+	}
+}
+
+func (gen *OpenAPI) property(table Table, col Column) *OpenAPISchema {
+	t := strings.Replace(col.DataType, "[]", "", 1)
+	node := gen.convertType(t)
+	if o, ok := gen.config.TypeOverrides.find(table, col); ok {
+		node = &OpenAPISchema{}
+		if strings.Contains(o.Type, "#") {
+			node.Ref = o.Type
+		} else {
+			node.Type = o.Type
+		}
+		// keywords are checked by validateSchemaOverrides
+		keywords, _ := schemaKeywords(o.Annotations)
+		node.Format = keywords["format"]
+		node.Pattern = keywords["pattern"]
+	}
+	if col.Length > 0 && node.Type == "string" {
+		node.MaxLength = col.Length
+	}
+	if col.Array {
+		node = &OpenAPISchema{
+			Type:  "array",
+			Items: node,
+		}
+	}
+	if !col.NotNull {
+		if node.Ref != "" {
+			// siblings of $ref are ignored in OpenAPI 3.0
+			node = &OpenAPISchema{AllOf: []*OpenAPISchema{node}}
+		}
+		node.Nullable = true
+	}
+	node.Description = strings.Replace(col.Comment.String, "\n", " ", -1)
+	return node
+}
+
+func (gen *OpenAPI) convertType(t string) *OpenAPISchema {
+	if pt, ok := lookupPgType(t); ok {
+		switch pt {
+		case PgInt16, PgInt32:
+			return &OpenAPISchema{Type: "integer", Format: "int32"}
+		case PgInt64:
+			return &OpenAPISchema{Type: "integer", Format: "int64"}
+		case PgFloat32:
+			return &OpenAPISchema{Type: "number", Format: "float"}
+		case PgFloat64:
+			return &OpenAPISchema{Type: "number", Format: "double"}
+		case PgNumeric:
+			return &OpenAPISchema{Type: "number"}
+		case PgBool:
+			return &OpenAPISchema{Type: "boolean"}
+		case PgUUID:
+			return &OpenAPISchema{Type: "string", Format: "uuid"}
+		case PgDate:
+			return &OpenAPISchema{Type: "string", Format: "date"}
+		case PgTimestamp, PgTimestampTZ:
+			return &OpenAPISchema{Type: "string", Format: "date-time"}
+		case PgBytes:
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		case PgJSON:
+			// any JSON value
+			return &OpenAPISchema{}
+		}
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	typ, err := gen.ins.FindType(t)
+	if err == nil {
+		return &OpenAPISchema{Ref: "#/components/schemas/" + gen.schemaName(typ.Schema, typ.Name)}
+	}
+	warnUnknownType(OpenAPITypeName, t, "string")
+	return &OpenAPISchema{Type: "string"}
+}
+
+// yamlNode is a JSON value decoded with its key order.
+type yamlNode struct {
+	keys   []string
+	values []*yamlNode // values of an object or items of an array
+	array  bool
+	object bool
+	scalar string
+}
+
+// jsonToYAML converts JSON to block style YAML keeping the key order.
+func jsonToYAML(b []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	node, err := decodeYAMLNode(dec)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeYAMLNode(&buf, node, 0)
+	return buf.Bytes(), nil
+}
+
+func decodeYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		node := &yamlNode{object: v == '{', array: v == '['}
+		for dec.More() {
+			if node.object {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, key.(string))
+			}
+			child, err := decodeYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, child)
+		}
+		// closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yamlNode{scalar: yamlString(v)}, nil
+	case json.Number:
+		return &yamlNode{scalar: v.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(v)}, nil
+	default:
+		return &yamlNode{scalar: "null"}, nil
+	}
+}
+
+func (n *yamlNode) inline() (string, bool) {
+	switch {
+	case n.object && len(n.values) == 0:
+		return "{}", true
+	case n.array && len(n.values) == 0:
+		return "[]", true
+	case !n.object && !n.array:
+		return n.scalar, true
+	}
+	return "", false
+}
+
+func writeYAMLNode(buf *bytes.Buffer, node *yamlNode, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for i, child := range node.values {
+		if node.object {
+			buf.WriteString(pad + yamlString(node.keys[i]) + ":")
+		} else {
+			buf.WriteString(pad + "-")
+		}
+		if s, ok := child.inline(); ok {
+			buf.WriteString(" " + s + "\n")
+			continue
+		}
+		if node.array && child.object {
+			// "- key: value" style, the first line shares the dash
+			var sub bytes.Buffer
+			writeYAMLNode(&sub, child, indent+1)
+			buf.WriteString(" " + strings.TrimPrefix(sub.String(), pad+"  "))
+			continue
+		}
+		buf.WriteString("\n")
+		writeYAMLNode(buf, child, indent+1)
+	}
+}
+
+var regYAMLPlain = regexp.MustCompile(`^[A-Za-z_$/#.][A-Za-z0-9_$/#. ()-]*$`)
+
+// yamlString returns s as a plain scalar if it is safe, otherwise quoted.
+func yamlString(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "null", "yes", "no", "on", "off", "y", "n", "~", ".inf", ".nan":
+		return strconv.Quote(s)
+	}
+	if !regYAMLPlain.MatchString(s) || strings.HasPrefix(s, "#") || strings.HasSuffix(s, " ") || strings.Contains(s, " #") {
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	return s
+}
+
+func loadOpenAPIConfig(root string, raw json.RawMessage) (OpenAPIConfig, error) {
+	var oc OpenAPIConfig
+	if err := json.Unmarshal(raw, &oc); err != nil {
+		return oc, fmt.Errorf("openapi config error: %s", err)
+	}
+	output := filePathJoinRoot(root, oc.Output)
+	if err := DirExists(output); err != nil {
+		return oc, fmt.Errorf("openapi output is not exists: %s", oc.Output)
+	}
+	switch oc.Format {
+	case "":
+		oc.Format = "yaml"
+	case "yaml", "json":
+	default:
+		return oc, fmt.Errorf("openapi unknown format: %s", oc.Format)
+	}
+	if err := validateSchemaOverrides(oc.TypeOverrides); err != nil {
+		return oc, fmt.Errorf("openapi %s", err)
+	}
+	if oc.FileName == "" {
+		oc.FileName = "components." + oc.Format
+	}
+	if oc.Title == "" {
+		oc.Title = "pg2any components"
+	}
+	if oc.Version == "" {
+		oc.Version = "1.0.0"
+	}
+	return oc, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func newTestOpenAPI(config OpenAPIConfig) OpenAPI {
+	return OpenAPI{
+		config: config,
+		ins: InspectResult{
+			Tables: []Table{
+				{
+					Schema: "public",
+					Name:   "purchase_order",
+					Columns: []Column{
+						{Name: "id", DataType: "bigint", NotNull: true, Serial: true},
+						{Name: "code", DataType: "character varying(20)", Length: 20, NotNull: true},
+						{Name: "status", DataType: "order_status"},
+						{Name: "created_at", DataType: "timestamp with time zone", NotNull: true, DefaultValue: sql.NullString{String: "now()", Valid: true}},
+					},
+				},
+			},
+			Types: []Type{{Schema: "public", Name: "order_status", Values: []string{"open", "closed"}}},
+		},
+	}
+}
+
+func TestOpenAPIYAML(t *testing.T) {
+	gen := newTestOpenAPI(OpenAPIConfig{Format: "yaml", Title: "db", Version: "1.0.0", CreateVariants: true})
+	var buf bytes.Buffer
+	if err := gen.write(&buf, gen.buildDocument()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"openapi: \"3.0.3\"\n",
+		"paths: {}\n",
+		"components:\n  schemas:\n    PurchaseOrder:\n      title: purchase_order\n      type: object\n      properties:\n        id:\n          type: integer\n          format: int64\n",
+		"        status:\n          nullable: true\n          allOf:\n            - $ref: \"#/components/schemas/OrderStatus\"\n",
+		"          maxLength: 20\n",
+		"      required:\n        - id\n        - code\n        - created_at\n",
+		"    PurchaseOrderCreate:\n",
+		"      required:\n        - code\n",
+		"    OrderStatus:\n      title: order_status\n      type: string\n      enum:\n        - open\n        - closed\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	create := out[strings.Index(out, "PurchaseOrderCreate:"):strings.Index(out, "OrderStatus:")]
+	if strings.Contains(create, "id:") || strings.Contains(create, "created_at") {
+		t.Errorf("create variant should omit generated columns\n%s", create)
+	}
+}
+
+func TestOpenAPITypeOverrides(t *testing.T) {
+	gen := newTestOpenAPI(OpenAPIConfig{
+		Format: "yaml",
+		TypeOverrides: TypeOverrides{
+			"purchase_order.code": {Type: "string", Annotations: []string{"pattern: ^[A-Z]+$"}},
+			"bigint":              {Type: "string", Annotations: []string{"format: int64"}},
+			"order_status":        {Type: "common.yaml#/components/schemas/Status"},
+		},
+	})
+	var buf bytes.Buffer
+	if err := gen.write(&buf, gen.buildDocument()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"        id:\n          type: string\n          format: int64\n",
+		"        code:\n          type: string\n          pattern: \"^[A-Z]+$\"\n          maxLength: 20\n",
+		"        status:\n          nullable: true\n          allOf:\n            - $ref: common.yaml#/components/schemas/Status\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	for _, a := range []string{"pattern ^[A-Z]+$", "minLength: 1"} {
+		if err := validateSchemaOverrides(TypeOverrides{"text": {Type: "string", Annotations: []string{a}}}); err == nil {
+			t.Errorf("%s: expected an error", a)
+		}
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	ff := [][]string{
+		{"string", "string"},
+		{"date-time", "date-time"},
+		{"$ref", "$ref"},
+		{"true", `"true"`},
+		{"a: b", `"a: b"`},
+		{"#/components", `"#/components"`},
+		{"1.0", `"1.0"`},
+		{".inf", `".inf"`},
+		{"-.Inf", `"-.Inf"`},
+		{".NaN", `".NaN"`},
+		{"", `""`},
+	}
+	for _, d := range ff {
+		if actual := yamlString(d[0]); actual != d[1] {
+			t.Errorf("expected %s, actual: %s", d[1], actual)
+		}
+	}
+}
@@ -1,13 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
@@ -18,13 +19,25 @@ import (
 type ProtoBufConfig struct {
 	Output             string   `json:"output"`
 	Templates          string   `json:"templates"`
-	Overwrites         []string `json:"overwrites"`
+	Overwrites         []string `json:"overwrites"` // files (regexp) which may be replaced entirely
 	PackageName        string   `json:"package_name"`
 	EnumDir            string   `json:"enum_dir"`
+	MessageDir         string   `json:"message_dir"` // import path of message files
 	JavaPackage        string   `json:"java_package"`
 	GoPackage          string   `json:"go_package"`
 	IgnoreTables       []string `json:"ignore_tables"`
 	UseStringToNumeric bool     `json:"use_string_to_numeric"`
+	LockFile           string   `json:"lock_file"`
+	NullableStrategy   string   `json:"nullable_strategy"`
+	EnumFilePerType    bool     `json:"enum_file_per_type"`
+	OmitEnumPrefix     bool     `json:"omit_enum_value_prefix"`
+	DateType           string   `json:"date_type"`
+	NumericType        string   `json:"numeric_type"`
+	JSONType           string   `json:"json_type"`
+	IntervalType       string   `json:"interval_type"`
+	GenerateService    bool     `json:"generate_service"`
+
+	TypeOverrides TypeOverrides `json:"type_overrides"`
 }
 
 type ProtoBuf struct {
@@ -33,6 +46,7 @@ type ProtoBuf struct {
 	ins      InspectResult
 	template *template.Template
 	root     string
+	lock     *ProtoBufLock
 }
 
 type ProtoBufMember struct {
@@ -41,16 +55,71 @@ type ProtoBufMember struct {
 	Type       string
 	Comment    string
 	Index      int
+	Options    string   // field options such as "deprecated = true"
+	Imports    []string // imports of type_overrides
 }
 
 type ProtoBufTypeMember struct {
-	Name    string
-	Comment string
-	Values  string
+	Name     string
+	Comment  string
+	Values   []string
+	Reserved []string
 }
 
 const ProtoBufTypeName = "protobuf"
 
+// nullable_strategy values
+const (
+	ProtoBufNullableNone     = "none"
+	ProtoBufNullableOptional = "optional"
+	ProtoBufNullableWrappers = "wrappers"
+)
+
+// ProtoBufDecimal is the numeric_type which uses the Decimal message written
+// by pg2any, for those who do not use googleapis.
+const ProtoBufDecimal = "decimal"
+
+// Types which can be used as date_type, numeric_type, json_type and
+// interval_type. The first one is the default.
+var (
+	protoBufDateTypes     = []string{"string", "google.type.Date"}
+	protoBufNumericTypes  = []string{"int64", "string", "google.type.Decimal", ProtoBufDecimal}
+	protoBufJSONTypes     = []string{"map", "google.protobuf.Struct", "google.protobuf.Value"}
+	protoBufIntervalTypes = []string{"string", "google.protobuf.Duration"}
+)
+
+// protoBufWrappers maps scalar types to the well known wrapper types.
+var protoBufWrappers = map[string]string{
+	"double": "google.protobuf.DoubleValue",
+	"float":  "google.protobuf.FloatValue",
+	"int64":  "google.protobuf.Int64Value",
+	"uint64": "google.protobuf.UInt64Value",
+	"int32":  "google.protobuf.Int32Value",
+	"uint32": "google.protobuf.UInt32Value",
+	"bool":   "google.protobuf.BoolValue",
+	"string": "google.protobuf.StringValue",
+	"bytes":  "google.protobuf.BytesValue",
+}
+
+// protoBufImports maps message types to the file which defines them.
+var protoBufImports = map[string]string{
+	"google.protobuf.Timestamp":   "google/protobuf/timestamp.proto",
+	"google.protobuf.Duration":    "google/protobuf/duration.proto",
+	"google.protobuf.Struct":      "google/protobuf/struct.proto",
+	"google.protobuf.Value":       "google/protobuf/struct.proto",
+	"google.type.Date":            "google/type/date.proto",
+	"google.type.Decimal":         "google/type/decimal.proto",
+	"google.protobuf.DoubleValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.FloatValue":  "google/protobuf/wrappers.proto",
+	"google.protobuf.Int64Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.UInt64Value": "google/protobuf/wrappers.proto",
+	"google.protobuf.Int32Value":  "google/protobuf/wrappers.proto",
+	"google.protobuf.UInt32Value": "google/protobuf/wrappers.proto",
+	"google.protobuf.BoolValue":   "google/protobuf/wrappers.proto",
+	"google.protobuf.StringValue": "google/protobuf/wrappers.proto",
+	"google.protobuf.BytesValue":  "google/protobuf/wrappers.proto",
+}
+
 func NewProtoBuf(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
 	config, err := loadProtoBufConfig(root, raw)
 	if err != nil {
@@ -79,103 +148,334 @@ func (gen *ProtoBuf) Build(ins InspectResult) error {
 	t := template.Must(template.ParseGlob(tdir))
 	gen.template = t
 
+	lockFile := filePathJoinRoot(gen.root, gen.config.LockFile)
+	lock, err := loadProtoBufLock(lockFile)
+	if err != nil {
+		return errors.Wrap(err, "build load lock file")
+	}
+	gen.lock = lock
+
+	out := &regionWriter{
+		dir:        filePathJoinRoot(gen.root, gen.config.Output),
+		overwrites: gen.config.Overwrites,
+	}
+
 	// Build tables
 	for _, table := range gen.ins.Tables {
 		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
 			continue
 		}
-		fileName := SnakeToUpperCamel(table.Name) + "Message.proto"
-		file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), fileName))
+		var buf bytes.Buffer
+		saved := gen.lock.clone()
+		if err := gen.buildTable(&buf, table); err != nil {
+			return errors.Wrap(err, "build write table")
+		}
+		fileName := filepath.Join(schemaDir(table.Schema), SnakeToUpperCamel(table.Name)+"Message.proto")
+		written, err := out.write(fileName, buf.Bytes())
 		if err != nil {
-			return errors.Wrap(err, "build create file")
+			return errors.Wrap(err, "build write file")
 		}
-		if err := gen.buildTable(file, table); err != nil {
-			file.Close()
-			return errors.Wrap(err, "build write table")
+		if !written {
+			// keep the numbers of the file on disk
+			gen.lock = saved
+		}
+
+		if !gen.config.GenerateService {
+			continue
+		}
+		if len(table.PrimaryKeys) == 0 {
+			log.Printf("skip service of %s: no primary key", qualifiedName(table.Schema, table.Name))
+			continue
+		}
+		buf.Reset()
+		if err := gen.buildService(&buf, table); err != nil {
+			return errors.Wrap(err, "build write service")
+		}
+		if !written {
+			gen.lock = saved
+		}
+		fileName = filepath.Join(schemaDir(table.Schema), SnakeToUpperCamel(table.Name)+"Service.proto")
+		if _, err := out.write(fileName, buf.Bytes()); err != nil {
+			return errors.Wrap(err, "build write file")
 		}
-		file.Close()
 	}
 
-	// Build types
-	enumFileName := "enum.proto"
-	file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), enumFileName))
-	defer file.Close()
-	if err != nil {
-		return errors.Wrap(err, "build create file")
+	if gen.config.NumericType == ProtoBufDecimal {
+		var buf bytes.Buffer
+		if err := gen.buildDecimal(&buf); err != nil {
+			return errors.Wrap(err, "build write decimal")
+		}
+		if _, err := out.write("decimal.proto", buf.Bytes()); err != nil {
+			return errors.Wrap(err, "build write file")
+		}
 	}
-	if err := gen.buildType(file, gen.ins.Types); err != nil {
-		return errors.Wrap(err, "build write type")
+
+	// Build types, one enum file per schema or per type
+	schemas, types := typesBySchema(gen.ins.Types)
+	for _, schema := range schemas {
+		files := [][]Type{types[schema]}
+		if gen.config.EnumFilePerType {
+			files = nil
+			for _, typ := range types[schema] {
+				files = append(files, []Type{typ})
+			}
+		}
+		for _, ts := range files {
+			var buf bytes.Buffer
+			saved := gen.lock.clone()
+			if err := gen.buildType(&buf, schema, ts); err != nil {
+				return errors.Wrap(err, "build write type")
+			}
+			enumFileName := filepath.Join(schemaDir(schema), gen.enumFileName(ts[0]))
+			written, err := out.write(enumFileName, buf.Bytes())
+			if err != nil {
+				return errors.Wrap(err, "build write file")
+			}
+			if !written {
+				gen.lock = saved
+			}
+		}
+	}
+
+	if err := gen.lock.save(lockFile); err != nil {
+		return errors.Wrap(err, "build save lock file")
 	}
 
-	return nil
+	return out.err()
 }
 
 func (gen *ProtoBuf) buildTable(wr io.Writer, table Table) error {
+	members, reserved := gen.members(table)
 	return gen.template.ExecuteTemplate(wr, "message", map[string]interface{}{
-		"package_name": gen.config.PackageName,
-		"java_package": gen.config.JavaPackage,
+		"package_name": gen.packageName(table.Schema),
+		"java_package": schemaPackage(gen.config.JavaPackage, table.Schema),
 		"go_package":   gen.config.GoPackage,
 		"now":          time.Now().UTC().Format(time.RFC3339),
 		"comment":      table.Comment.String,
 		"table":        table,
 		"name":         SnakeToUpperCamel(table.Name) + "Message",
-		"member":       gen.members(table),
-		"enum_path":    filepath.Join(gen.config.EnumDir, "enum.proto"),
+		"member":       members,
+		"reserved":     reserved,
+		"imports":      gen.imports(table, members),
 	})
 }
 
-func (gen *ProtoBuf) members(table Table) []ProtoBufMember {
-	var ret []ProtoBufMember
+// buildService writes a CRUD service of table. Get and Delete are keyed on
+// the primary key columns.
+func (gen *ProtoBuf) buildService(wr io.Writer, table Table) error {
+	members, _ := gen.members(table)
+	var keys []ProtoBufMember
+	for _, col := range table.PrimaryKeys {
+		for _, m := range members {
+			if m.Name == col.Name {
+				m.Constraint = ""
+				m.Index = len(keys) + 1
+				keys = append(keys, m)
+			}
+		}
+	}
+	message := SnakeToUpperCamel(table.Name) + "Message"
+	imports := []string{
+		"google/protobuf/field_mask.proto",
+		filepath.Join(gen.config.MessageDir, schemaDir(table.Schema), message+".proto"),
+	}
+	for _, path := range gen.imports(Table{Schema: table.Schema, Columns: table.PrimaryKeys}, keys) {
+		if !contains(imports, path) {
+			imports = append(imports, path)
+		}
+	}
 
-	for i, col := range table.Columns {
+	return gen.template.ExecuteTemplate(wr, "service", map[string]interface{}{
+		"package_name": gen.packageName(table.Schema),
+		"java_package": schemaPackage(gen.config.JavaPackage, table.Schema),
+		"go_package":   gen.config.GoPackage,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+		"imports":      imports,
+		"name":         SnakeToUpperCamel(table.Name),
+		"plural":       SnakeToUpperCamel(pluralize(table.Name)),
+		"field":        table.Name,
+		"list_field":   pluralize(table.Name),
+		"message":      message,
+		"keys":         keys,
+	})
+}
+
+// packageName returns the proto package of schema.
+func (gen *ProtoBuf) packageName(schema string) string {
+	return schemaPackage(gen.config.PackageName, schema)
+}
+
+// imports returns the files which should be imported by table, well known
+// types first and then enums.
+func (gen *ProtoBuf) imports(table Table, members []ProtoBufMember) []string {
+	var ret []string
+	for _, m := range members {
+		var paths []string
+		if path, ok := protoBufImports[strings.TrimPrefix(m.Type, "repeated ")]; ok {
+			paths = append(paths, path)
+		}
+		for _, path := range append(paths, m.Imports...) {
+			if !contains(ret, path) {
+				ret = append(ret, path)
+			}
+		}
+	}
+	sort.Strings(ret)
+	if gen.config.NumericType == ProtoBufDecimal {
+		for _, m := range members {
+			if strings.TrimPrefix(m.Type, "repeated ") == gen.decimalType() {
+				ret = append(ret, filepath.Join(gen.config.EnumDir, "decimal.proto"))
+				break
+			}
+		}
+	}
+	return append(ret, gen.enumPaths(table)...)
+}
+
+// enumPaths returns the enum files which should be imported by table.
+func (gen *ProtoBuf) enumPaths(table Table) []string {
+	var ret []string
+	for _, col := range table.Columns {
+		typ, err := gen.ins.FindType(strings.Replace(col.DataType, "[]", "", 1))
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(gen.config.EnumDir, schemaDir(typ.Schema), gen.enumFileName(typ))
+		if !contains(ret, path) {
+			ret = append(ret, path)
+		}
+	}
+	return ret
+}
+
+// members returns the fields of table numbered by the lock file, and the
+// reserved statements of removed fields.
+func (gen *ProtoBuf) members(table Table) ([]ProtoBufMember, []string) {
+	var names []string
+	for _, col := range table.Columns {
+		names = append(names, col.Name)
+	}
+	lock := gen.lock.message(table.Schema+"."+table.Name, names)
+
+	var ret []ProtoBufMember
+	for _, col := range table.Columns {
+		typ := gen.convertType(col)
+		o, overridden := gen.config.TypeOverrides.find(table, col)
+		if overridden {
+			typ = o.Type
+		}
+		cons, typ := gen.nullable(col, typ)
 		m := ProtoBufMember{
-			Name:    col.Name,
-			Type:    gen.convertType(col),
-			Comment: strings.Replace(col.Comment.String, "\n", "", -1),
-			Index:   i + 1,
+			Constraint: cons,
+			Name:       col.Name,
+			Type:       typ,
+			Comment:    strings.Replace(col.Comment.String, "\n", "", -1),
+			Index:      lock.Fields[col.Name],
+			Options:    strings.Join(o.Annotations, ", "),
+			Imports:    o.Imports,
 		}
 		ret = append(ret, m)
 	}
-	return ret
+	return ret, lock.reserved(nil)
+}
+
+// nullable applies nullable_strategy to the field of col whose type is typ.
+// It returns the label and the type of the field. Enums have no wrapper type
+// so they become optional with the wrappers strategy too. Repeated fields,
+// maps and messages are left as is.
+func (gen *ProtoBuf) nullable(col Column, typ string) (string, string) {
+	if col.NotNull || gen.config.NullableStrategy == ProtoBufNullableNone || gen.config.NullableStrategy == "" {
+		return "", typ
+	}
+	if strings.HasPrefix(typ, "repeated ") || strings.HasPrefix(typ, "map<") {
+		return "", typ
+	}
+	wrapper, scalar := protoBufWrappers[typ]
+	if gen.config.NullableStrategy == ProtoBufNullableWrappers && scalar {
+		return "", wrapper
+	}
+	if _, err := gen.ins.FindType(col.DataType); scalar || err == nil {
+		return "optional", typ
+	}
+	return "", typ
+}
+
+// enumFileName returns the file name which defines typ.
+func (gen *ProtoBuf) enumFileName(typ Type) string {
+	if gen.config.EnumFilePerType {
+		return strings.ToLower(protoBufIdent(typ.Name)) + ".proto"
+	}
+	return "enum.proto"
 }
 
-func (gen *ProtoBuf) buildType(wr io.Writer, types []Type) error {
+// buildType writes enums of types. The zero value is TYPE_UNSPECIFIED and
+// labels are numbered from 1 by the lock file, so that inserting a label
+// does not renumber the others.
+func (gen *ProtoBuf) buildType(wr io.Writer, schema string, types []Type) error {
 	var members []ProtoBufTypeMember
 	for _, typ := range types {
-		name := SnakeToUpper(typ.Name)
-		var vs []string
-		for i, val := range typ.Values {
-			if isNumber(val) {
-				vs = append(vs, fmt.Sprintf("%s_VALUE_%s = %d;", name, SnakeToUpper(val), i))
-			} else {
-				vs = append(vs, fmt.Sprintf("%s_%s = %d;", name, SnakeToUpper(val), i))
-			}
+		lock := gen.lock.enum(typ.Schema+"."+typ.Name, typ.Values)
+		valueName := func(label string) string {
+			return gen.enumValueName(typ, label)
+		}
+
+		vs := []string{fmt.Sprintf("%s = 0;", enumUnspecified(typ))}
+		for _, val := range typ.Values {
+			vs = append(vs, fmt.Sprintf("%s = %d;", valueName(val), lock.Fields[val]))
 		}
 		m := ProtoBufTypeMember{
-			Name:    SnakeToUpperCamel(typ.Name),
-			Comment: typ.Comment.String,
-			Values:  "  " + strings.Join(vs, "\n  "),
+			Name:     SnakeToUpperCamel(typ.Name),
+			Comment:  typ.Comment.String,
+			Values:   vs,
+			Reserved: lock.reserved(valueName),
 		}
 		members = append(members, m)
 	}
 
 	return gen.template.ExecuteTemplate(wr, "enum", map[string]interface{}{
-		"package_name": gen.config.PackageName,
-		"java_package": gen.config.JavaPackage,
+		"package_name": gen.packageName(schema),
+		"java_package": schemaPackage(gen.config.JavaPackage, schema),
 		"go_package":   gen.config.GoPackage,
 		"now":          time.Now().UTC().Format(time.RFC3339),
 		"members":      members,
 	})
-	return nil
 }
 
-func (gen *ProtoBuf) enumExists(typeName string) bool {
-	for _, typ := range gen.ins.Types {
-		if typ.Name == typeName {
-			return true
+// enumValueName returns the name of the enum value of label. Values are
+// prefixed with the type name unless omit_enum_value_prefix is set. Labels
+// which start with a digit get "VALUE_", and a label which collides with
+// the zero value, such as "unspecified", gets "_VALUE".
+func (gen *ProtoBuf) enumValueName(typ Type, label string) string {
+	value := protoBufIdent(label)
+	if value == "" || (value[0] >= '0' && value[0] <= '9') {
+		value = strings.TrimSuffix("VALUE_"+value, "_")
+	}
+	if !gen.config.OmitEnumPrefix {
+		value = protoBufIdent(typ.Name) + "_" + value
+	}
+	if value == enumUnspecified(typ) {
+		value += "_VALUE"
+	}
+	return value
+}
+
+// enumUnspecified returns the name of the zero value of typ.
+func enumUnspecified(typ Type) string {
+	return SnakeToUpper(protoBufIdent(typ.Name)) + "_UNSPECIFIED"
+}
+
+// protoBufIdent converts s to UPPER_SNAKE_CASE, replacing characters which
+// can not be used in an identifier with "_".
+func protoBufIdent(s string) string {
+	var ret []rune
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			ret = append(ret, r)
+		} else if len(ret) > 0 && ret[len(ret)-1] != '_' {
+			ret = append(ret, '_')
 		}
 	}
-	return false
+	return strings.TrimSuffix(string(ret), "_")
 }
 
 func (gen *ProtoBuf) convertType(col Column) string {
@@ -187,57 +487,84 @@ func (gen *ProtoBuf) convertType(col Column) string {
 		col.DataType = strings.Replace(col.DataType, "[]", "", 1)
 	}
 
-	switch col.DataType {
-	case "text":
-		return array + "string"
-	case "int", "integer":
-		return array + "int32"
-	case "float":
-		return array + "float"
-	case "double", "double precision":
-		return array + "double"
-	case "bigint":
-		return array + "int64"
-	case "serial":
-		return array + "int32"
-	case "bigserial":
-		return array + "int64"
-	case "uuid":
-		return array + "string"
-	case "bytea":
-		return array + "bytes"
-	case "numeric":
-		if gen.config.UseStringToNumeric {
-			return array + "string"
-		}
-		return array + "int64"
-	case "date":
-		return array + "string"
-	case "boolean":
-		return array + "bool"
-	case "json", "jsonb":
-		return array + "map<string, string>"
-	default:
-		// "timestamp with time zone", "timestamp without time zone", "timestamp(n) with time zone"
-		if strings.HasSuffix(col.DataType, "time zone") {
-			return array + "google.protobuf.Timestamp"
+	if pt, ok := lookupPgType(col.DataType); ok {
+		return array + gen.pgType(pt)
+	}
+	typ, err := gen.ins.FindType(col.DataType)
+	if err == nil {
+		return array + gen.packageName(typ.Schema) + "." + SnakeToUpperCamel(typ.Name)
+	}
+	warnUnknownType(ProtoBufTypeName, col.DataType, "string")
+	return array + "string"
+}
+
+// pgType returns the type of pt. Types which have no counterpart are
+// string, the text representation of Postgres.
+func (gen *ProtoBuf) pgType(pt PgType) string {
+	switch pt {
+	case PgBool:
+		return "bool"
+	case PgInt16, PgInt32:
+		return "int32"
+	case PgInt64:
+		return "int64"
+	case PgFloat32:
+		return "float"
+	case PgFloat64:
+		return "double"
+	case PgNumeric, PgMoney:
+		return gen.numericType()
+	case PgBytes:
+		return "bytes"
+	case PgDate:
+		if gen.config.DateType != "" {
+			return gen.config.DateType
 		}
-		if strings.HasPrefix(col.DataType, "numeric") {
-			if gen.config.UseStringToNumeric {
-				return array + "string"
-			}
-			return array + "int64"
+	case PgTimestamp, PgTimestampTZ:
+		return "google.protobuf.Timestamp"
+	case PgInterval:
+		if gen.config.IntervalType != "" {
+			return gen.config.IntervalType
 		}
-		if strings.HasPrefix(col.DataType, "character") {
-			return array + "string"
+	case PgJSON:
+		if gen.config.JSONType != "" && gen.config.JSONType != "map" {
+			return gen.config.JSONType
 		}
+		return "map<string, string>"
+	}
+	return "string"
+}
 
-		typ, err := gen.ins.FindType(col.DataType)
-		if err == nil {
-			return array + gen.config.PackageName + "." + SnakeToUpperCamel(typ.Name)
+// numericType returns the type of numeric columns.
+func (gen *ProtoBuf) numericType() string {
+	switch gen.config.NumericType {
+	case "":
+		if gen.config.UseStringToNumeric {
+			return "string"
 		}
+		return "int64"
+	case ProtoBufDecimal:
+		return gen.decimalType()
+	}
+	return gen.config.NumericType
+}
+
+// decimalType returns the full name of the Decimal message written by
+// pg2any.
+func (gen *ProtoBuf) decimalType() string {
+	if pkg := gen.packageName(DefaultSchema); pkg != "" {
+		return pkg + ".Decimal"
 	}
-	return array + col.DataType
+	return "Decimal"
+}
+
+func (gen *ProtoBuf) buildDecimal(wr io.Writer) error {
+	return gen.template.ExecuteTemplate(wr, "decimal", map[string]interface{}{
+		"package_name": gen.packageName(DefaultSchema),
+		"java_package": gen.config.JavaPackage,
+		"go_package":   gen.config.GoPackage,
+		"now":          time.Now().UTC().Format(time.RFC3339),
+	})
 }
 
 func loadProtoBufConfig(root string, raw json.RawMessage) (ProtoBufConfig, error) {
@@ -249,5 +576,33 @@ func loadProtoBufConfig(root string, raw json.RawMessage) (ProtoBufConfig, error
 	if err := DirExists(output); err != nil {
 		return pbc, fmt.Errorf("protobuf output is not exists: %s", pbc.Output)
 	}
+	if pbc.LockFile == "" {
+		pbc.LockFile = filepath.Join(pbc.Output, ProtoBufLockFileName)
+	}
+	switch pbc.NullableStrategy {
+	case "":
+		pbc.NullableStrategy = ProtoBufNullableNone
+	case ProtoBufNullableNone, ProtoBufNullableOptional, ProtoBufNullableWrappers:
+	default:
+		return pbc, fmt.Errorf("protobuf unknown nullable_strategy: %s", pbc.NullableStrategy)
+	}
+	mappings := []struct {
+		name  string
+		value string
+		types []string
+	}{
+		{"date_type", pbc.DateType, protoBufDateTypes},
+		{"numeric_type", pbc.NumericType, protoBufNumericTypes},
+		{"json_type", pbc.JSONType, protoBufJSONTypes},
+		{"interval_type", pbc.IntervalType, protoBufIntervalTypes},
+	}
+	for _, m := range mappings {
+		if m.value != "" && !contains(m.types, m.value) {
+			return pbc, fmt.Errorf("protobuf unknown %s: %s", m.name, m.value)
+		}
+	}
+	if err := pbc.TypeOverrides.validate(); err != nil {
+		return pbc, fmt.Errorf("protobuf %s", err)
+	}
 	return pbc, nil
 }
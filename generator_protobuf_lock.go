@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProtoBufLockFileName is the default name of the lock file which pins field
+// numbers of generated messages.
+const ProtoBufLockFileName = "pg2any.lock.json"
+
+// Field numbers 19000 through 19999 are reserved for the protobuf
+// implementation.
+const (
+	protoBufReservedFrom = 19000
+	protoBufReservedTo   = 19999
+)
+
+// ProtoBufLock keeps field numbers of every message across generations so
+// that dropping or reordering columns does not break wire compatibility.
+type ProtoBufLock struct {
+	Messages map[string]*ProtoBufLockMessage `json:"messages"`
+	Enums    map[string]*ProtoBufLockMessage `json:"enums,omitempty"`
+}
+
+// ProtoBufLockMessage is the numbering of one message or enum. Numbers and
+// names of removed columns (or labels) are kept as reserved and never reused.
+type ProtoBufLockMessage struct {
+	Fields          map[string]int `json:"fields"`
+	ReservedNumbers []int          `json:"reserved_numbers,omitempty"`
+	ReservedNames   []string       `json:"reserved_names,omitempty"`
+}
+
+func newProtoBufLock() *ProtoBufLock {
+	return &ProtoBufLock{
+		Messages: make(map[string]*ProtoBufLockMessage),
+		Enums:    make(map[string]*ProtoBufLockMessage),
+	}
+}
+
+// loadProtoBufLock reads the lock file. A missing file gives an empty lock.
+func loadProtoBufLock(path string) (*ProtoBufLock, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return newProtoBufLock(), nil
+		}
+		return nil, err
+	}
+	lock := newProtoBufLock()
+	if err := json.Unmarshal(buf, lock); err != nil {
+		return nil, fmt.Errorf("protobuf lock file error: %s", err)
+	}
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]*ProtoBufLockMessage)
+	}
+	if lock.Enums == nil {
+		lock.Enums = make(map[string]*ProtoBufLockMessage)
+	}
+	return lock, nil
+}
+
+func (lock *ProtoBufLock) save(path string) error {
+	buf, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+// clone returns a deep copy of lock.
+func (lock *ProtoBufLock) clone() *ProtoBufLock {
+	ret := newProtoBufLock()
+	for key, m := range lock.Messages {
+		ret.Messages[key] = m.clone()
+	}
+	for key, m := range lock.Enums {
+		ret.Enums[key] = m.clone()
+	}
+	return ret
+}
+
+func (m *ProtoBufLockMessage) clone() *ProtoBufLockMessage {
+	ret := &ProtoBufLockMessage{
+		Fields:          make(map[string]int),
+		ReservedNumbers: append([]int(nil), m.ReservedNumbers...),
+		ReservedNames:   append([]string(nil), m.ReservedNames...),
+	}
+	for name, n := range m.Fields {
+		ret.Fields[name] = n
+	}
+	return ret
+}
+
+// message returns the numbering of the message key after synchronizing it
+// with the current field names.
+func (lock *ProtoBufLock) message(key string, names []string) *ProtoBufLockMessage {
+	return syncProtoBufLock(lock.Messages, key, names)
+}
+
+// enum returns the numbering of the enum key after synchronizing it with
+// the current labels. Labels are numbered from 1 since 0 is UNSPECIFIED.
+func (lock *ProtoBufLock) enum(key string, labels []string) *ProtoBufLockMessage {
+	return syncProtoBufLock(lock.Enums, key, labels)
+}
+
+func syncProtoBufLock(locks map[string]*ProtoBufLockMessage, key string, names []string) *ProtoBufLockMessage {
+	m, ok := locks[key]
+	if !ok {
+		m = &ProtoBufLockMessage{}
+		locks[key] = m
+	}
+	m.sync(names)
+	return m
+}
+
+// sync reserves the fields which are not in names and numbers the new ones
+// after the largest number ever used.
+func (m *ProtoBufLockMessage) sync(names []string) {
+	if m.Fields == nil {
+		m.Fields = make(map[string]int)
+	}
+
+	next := 1
+	for _, n := range m.Fields {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	for _, n := range m.ReservedNumbers {
+		if n >= next {
+			next = n + 1
+		}
+	}
+
+	for name, n := range m.Fields {
+		if contains(names, name) {
+			continue
+		}
+		delete(m.Fields, name)
+		m.ReservedNumbers = append(m.ReservedNumbers, n)
+		if !contains(m.ReservedNames, name) {
+			m.ReservedNames = append(m.ReservedNames, name)
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := m.Fields[name]; ok {
+			continue
+		}
+		// a column added again gets a new number, its old one stays reserved
+		m.ReservedNames = remove(m.ReservedNames, name)
+		if next >= protoBufReservedFrom && next <= protoBufReservedTo {
+			next = protoBufReservedTo + 1
+		}
+		m.Fields[name] = next
+		next++
+	}
+
+	sort.Ints(m.ReservedNumbers)
+	sort.Strings(m.ReservedNames)
+}
+
+// reserved returns the statements which reserve removed fields. If name is
+// not nil, it converts reserved names to the names used in the proto file.
+func (m *ProtoBufLockMessage) reserved(name func(string) string) []string {
+	var ret []string
+	if len(m.ReservedNumbers) > 0 {
+		var ns []string
+		for _, n := range m.ReservedNumbers {
+			ns = append(ns, strconv.Itoa(n))
+		}
+		ret = append(ret, "reserved "+strings.Join(ns, ", ")+";")
+	}
+	if len(m.ReservedNames) > 0 {
+		var ns []string
+		for _, n := range m.ReservedNames {
+			if name != nil {
+				n = name(n)
+			}
+			ns = append(ns, strconv.Quote(n))
+		}
+		ret = append(ret, "reserved "+strings.Join(ns, ", ")+";")
+	}
+	return ret
+}
+
+func remove(list []string, s string) []string {
+	var ret []string
+	for _, l := range list {
+		if l != s {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestProtoBufLockSync(t *testing.T) {
+	lock := newProtoBufLock()
+
+	m := lock.message("public.item", []string{"id", "name", "price"})
+	expected := map[string]int{"id": 1, "name": 2, "price": 3}
+	if !reflect.DeepEqual(m.Fields, expected) {
+		t.Errorf("expected %v, actual: %v", expected, m.Fields)
+	}
+
+	// reorder, drop "name" and add "stock"
+	m = lock.message("public.item", []string{"price", "stock", "id"})
+	expected = map[string]int{"id": 1, "price": 3, "stock": 4}
+	if !reflect.DeepEqual(m.Fields, expected) {
+		t.Errorf("expected %v, actual: %v", expected, m.Fields)
+	}
+	if !reflect.DeepEqual(m.ReservedNumbers, []int{2}) || !reflect.DeepEqual(m.ReservedNames, []string{"name"}) {
+		t.Errorf("unexpected reserved: %v %v", m.ReservedNumbers, m.ReservedNames)
+	}
+
+	// adding "name" again does not reuse the reserved number
+	m = lock.message("public.item", []string{"id", "name", "price", "stock"})
+	if m.Fields["name"] != 5 {
+		t.Errorf("expected 5, actual: %d", m.Fields["name"])
+	}
+	if !reflect.DeepEqual(m.ReservedNumbers, []int{2}) || len(m.ReservedNames) != 0 {
+		t.Errorf("unexpected reserved: %v %v", m.ReservedNumbers, m.ReservedNames)
+	}
+	expectedReserved := []string{"reserved 2;"}
+	if actual := m.reserved(nil); !reflect.DeepEqual(actual, expectedReserved) {
+		t.Errorf("expected %v, actual: %v", expectedReserved, actual)
+	}
+}
+
+func TestProtoBufLockSkipImplementationRange(t *testing.T) {
+	m := &ProtoBufLockMessage{
+		Fields: map[string]int{"id": protoBufReservedFrom - 1},
+	}
+	m.sync([]string{"id", "name"})
+	if m.Fields["name"] != protoBufReservedTo+1 {
+		t.Errorf("expected %d, actual: %d", protoBufReservedTo+1, m.Fields["name"])
+	}
+}
+
+func TestProtoBufLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg2any")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ProtoBufLockFileName)
+
+	lock, err := loadProtoBufLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock.message("public.item", []string{"id", "name"})
+	lock.message("public.item", []string{"id"})
+	if err := lock.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadProtoBufLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lock, loaded) {
+		t.Errorf("expected %v, actual: %v", lock, loaded)
+	}
+}
+
+func TestProtoBufReserved(t *testing.T) {
+	gen := ProtoBuf{
+		template: template.Must(template.ParseGlob("templates/protobuf/*.tmpl")),
+		lock:     newProtoBufLock(),
+	}
+	table := Table{
+		Schema: "public",
+		Name:   "item",
+		Columns: []Column{
+			{Name: "id", DataType: "bigint"},
+			{Name: "name", DataType: "text"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+
+	table.Columns = []Column{{Name: "id", DataType: "bigint"}, {Name: "note", DataType: "text"}}
+	buf.Reset()
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"message ItemMessage {\n  reserved 2;\n  reserved \"name\";\n",
+		" int64 id = 1;",
+		" string note = 3;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestProtoBufLockSkippedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg2any")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	lockFile := filepath.Join(dir, ProtoBufLockFileName)
+	gen := ProtoBuf{
+		config: ProtoBufConfig{
+			Output:           dir,
+			Templates:        "templates/protobuf",
+			PackageName:      "pg",
+			LockFile:         lockFile,
+			NullableStrategy: ProtoBufNullableNone,
+		},
+	}
+	table := Table{
+		Schema:  "public",
+		Name:    "item",
+		Columns: []Column{{Name: "id", DataType: "bigint"}, {Name: "name", DataType: "text"}},
+	}
+	if err := gen.Build(InspectResult{Tables: []Table{table}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a region which the new file does not have, so the file is skipped
+	path := filepath.Join(dir, "ItemMessage.proto")
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := string(buf) + "// pg2any:begin extra\nfoo\n// pg2any:end extra\n"
+	if err := ioutil.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	table.Columns = []Column{{Name: "id", DataType: "bigint"}, {Name: "note", DataType: "text"}}
+	if err := gen.Build(InspectResult{Tables: []Table{table}}); err == nil {
+		t.Fatal("error expected on the skipped file")
+	}
+	lock, err := loadProtoBufLock(lockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := lock.Messages["public.item"]
+	expected := map[string]int{"id": 1, "name": 2}
+	if !reflect.DeepEqual(m.Fields, expected) || len(m.ReservedNumbers) != 0 {
+		t.Errorf("lock should match the skipped file, actual: %v %v", m.Fields, m.ReservedNumbers)
+	}
+}
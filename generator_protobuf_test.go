@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func newTestProtoBuf(config ProtoBufConfig) ProtoBuf {
+	config.PackageName = "pg"
+	return ProtoBuf{
+		config: config,
+		ins: InspectResult{
+			Types: []Type{{Schema: "public", Name: "status", Values: []string{"active", "closed"}}},
+		},
+		template: template.Must(template.ParseGlob("templates/protobuf/*.tmpl")),
+		lock:     newProtoBufLock(),
+	}
+}
+
+func nullableTable() Table {
+	return Table{
+		Schema: "public",
+		Name:   "item",
+		Columns: []Column{
+			{Name: "id", DataType: "bigint", NotNull: true},
+			{Name: "name", DataType: "text"},
+			{Name: "status", DataType: "status"},
+			{Name: "tags", DataType: "text[]"},
+			{Name: "created_at", DataType: "timestamp with time zone"},
+		},
+	}
+}
+
+func TestProtoBufNullable(t *testing.T) {
+	cases := []struct {
+		strategy string
+		expected []string
+	}{
+		{ProtoBufNullableNone, []string{"int64", "string", "pg.Status", "repeated string", "google.protobuf.Timestamp"}},
+		{ProtoBufNullableOptional, []string{"int64", "optional string", "optional pg.Status", "repeated string", "google.protobuf.Timestamp"}},
+		{ProtoBufNullableWrappers, []string{"int64", "google.protobuf.StringValue", "optional pg.Status", "repeated string", "google.protobuf.Timestamp"}},
+	}
+	for _, c := range cases {
+		gen := newTestProtoBuf(ProtoBufConfig{NullableStrategy: c.strategy})
+		members, _ := gen.members(nullableTable())
+		var actual []string
+		for _, m := range members {
+			actual = append(actual, strings.TrimSpace(m.Constraint+" "+m.Type))
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %v, actual: %v", c.strategy, c.expected, actual)
+		}
+	}
+}
+
+func TestProtoBufImports(t *testing.T) {
+	gen := newTestProtoBuf(ProtoBufConfig{NullableStrategy: ProtoBufNullableWrappers})
+	var buf bytes.Buffer
+	if err := gen.buildTable(&buf, nullableTable()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	expected := "syntax = \"proto3\";\n\nimport \"google/protobuf/timestamp.proto\";\nimport \"google/protobuf/wrappers.proto\";\nimport \"enum.proto\";\n\npackage"
+	if !strings.HasPrefix(out, expected) {
+		t.Errorf("expected %s, actual: %s", expected, out)
+	}
+
+	gen = newTestProtoBuf(ProtoBufConfig{})
+	buf.Reset()
+	table := Table{Name: "plain", Columns: []Column{{Name: "id", DataType: "bigint"}}}
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); strings.Contains(out, "import") {
+		t.Errorf("unexpected import in\n%s", out)
+	}
+}
+
+func TestProtoBufEnum(t *testing.T) {
+	gen := newTestProtoBuf(ProtoBufConfig{})
+	typ := Type{Schema: "public", Name: "order_status", Values: []string{"new", "in progress", "1st"}}
+	var buf bytes.Buffer
+	if err := gen.buildType(&buf, "public", []Type{typ}); err != nil {
+		t.Fatal(err)
+	}
+	expected := `enum OrderStatus {
+  ORDER_STATUS_UNSPECIFIED = 0;
+  ORDER_STATUS_NEW = 1;
+  ORDER_STATUS_IN_PROGRESS = 2;
+  ORDER_STATUS_VALUE_1ST = 3;
+}`
+	if out := buf.String(); !strings.Contains(out, expected) {
+		t.Errorf("expected %s, actual: %s", expected, out)
+	}
+
+	// insert a label and drop another one
+	typ.Values = []string{"new", "paid", "1st"}
+	gen.config.OmitEnumPrefix = true
+	buf.Reset()
+	if err := gen.buildType(&buf, "public", []Type{typ}); err != nil {
+		t.Fatal(err)
+	}
+	expected = `enum OrderStatus {
+  reserved 2;
+  reserved "IN_PROGRESS";
+  ORDER_STATUS_UNSPECIFIED = 0;
+  NEW = 1;
+  PAID = 4;
+  VALUE_1ST = 3;
+}`
+	if out := buf.String(); !strings.Contains(out, expected) {
+		t.Errorf("expected %s, actual: %s", expected, out)
+	}
+}
+
+func TestProtoBufEnumUnspecifiedLabel(t *testing.T) {
+	gen := newTestProtoBuf(ProtoBufConfig{})
+	typ := Type{Schema: "public", Name: "state", Values: []string{"unspecified", "open"}}
+	var buf bytes.Buffer
+	if err := gen.buildType(&buf, "public", []Type{typ}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "  STATE_UNSPECIFIED = 0;\n  STATE_UNSPECIFIED_VALUE = 1;\n  STATE_OPEN = 2;\n"
+	if out := buf.String(); !strings.Contains(out, expected) {
+		t.Errorf("%q is not found in\n%s", expected, out)
+	}
+
+	gen.config.OmitEnumPrefix = true
+	if actual := gen.enumValueName(typ, "state_unspecified"); actual != "STATE_UNSPECIFIED_VALUE" {
+		t.Errorf("expected STATE_UNSPECIFIED_VALUE, actual: %s", actual)
+	}
+}
+
+func TestProtoBufEnumFileName(t *testing.T) {
+	gen := newTestProtoBuf(ProtoBufConfig{})
+	table := Table{Name: "item", Columns: []Column{{Name: "status", DataType: "status"}}}
+	if actual := gen.enumPaths(table); !reflect.DeepEqual(actual, []string{"enum.proto"}) {
+		t.Errorf("expected enum.proto, actual: %v", actual)
+	}
+	gen.config.EnumFilePerType = true
+	if actual := gen.enumPaths(table); !reflect.DeepEqual(actual, []string{"status.proto"}) {
+		t.Errorf("expected status.proto, actual: %v", actual)
+	}
+}
+
+func TestProtoBufIdent(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected string
+	}{
+		{"active", "ACTIVE"},
+		{"in_progress", "IN_PROGRESS"},
+		{"in progress", "IN_PROGRESS"},
+		{"-a--b-", "A_B"},
+		{"1", "1"},
+	}
+	for _, c := range cases {
+		if actual := protoBufIdent(c.in); actual != c.expected {
+			t.Errorf("expected %s, actual: %s", c.expected, actual)
+		}
+	}
+}
+
+func TestProtoBufTypeMappings(t *testing.T) {
+	table := Table{
+		Name: "item",
+		Columns: []Column{
+			{Name: "born_on", DataType: "date"},
+			{Name: "price", DataType: "numeric(10,2)"},
+			{Name: "attrs", DataType: "jsonb"},
+			{Name: "ttl", DataType: "interval"},
+		},
+	}
+	cases := []struct {
+		config  ProtoBufConfig
+		types   []string
+		imports []string
+	}{
+		{
+			ProtoBufConfig{},
+			[]string{"string", "int64", "map<string, string>", "string"},
+			nil,
+		},
+		{
+			ProtoBufConfig{DateType: "google.type.Date", NumericType: "google.type.Decimal", JSONType: "google.protobuf.Struct", IntervalType: "google.protobuf.Duration"},
+			[]string{"google.type.Date", "google.type.Decimal", "google.protobuf.Struct", "google.protobuf.Duration"},
+			[]string{"google/protobuf/duration.proto", "google/protobuf/struct.proto", "google/type/date.proto", "google/type/decimal.proto"},
+		},
+		{
+			ProtoBufConfig{NumericType: ProtoBufDecimal, JSONType: "google.protobuf.Value"},
+			[]string{"string", "pg.Decimal", "google.protobuf.Value", "string"},
+			[]string{"google/protobuf/struct.proto", "decimal.proto"},
+		},
+	}
+	for _, c := range cases {
+		gen := newTestProtoBuf(c.config)
+		members, _ := gen.members(table)
+		var types []string
+		for _, m := range members {
+			types = append(types, m.Type)
+		}
+		if !reflect.DeepEqual(types, c.types) {
+			t.Errorf("expected %v, actual: %v", c.types, types)
+		}
+		if imports := gen.imports(table, members); !reflect.DeepEqual(imports, c.imports) {
+			t.Errorf("expected %v, actual: %v", c.imports, imports)
+		}
+	}
+}
+
+func TestProtoBufService(t *testing.T) {
+	gen := newTestProtoBuf(ProtoBufConfig{})
+	table := compositeKeyTable()
+	table.Name = "order_entry"
+	var buf bytes.Buffer
+	if err := gen.buildService(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import \"google/protobuf/field_mask.proto\";\nimport \"OrderEntryMessage.proto\";\n\npackage pg;",
+		"service OrderEntryService {\n",
+		"  rpc GetOrderEntry(GetOrderEntryRequest) returns (OrderEntryMessage);\n",
+		"  rpc ListOrderEntries(ListOrderEntriesRequest) returns (ListOrderEntriesResponse);\n",
+		"  rpc DeleteOrderEntry(DeleteOrderEntryRequest) returns (DeleteOrderEntryResponse);\n",
+		"message GetOrderEntryRequest {\n  int64 tenant_id = 1;\n  string code = 2;\n}",
+		"  repeated OrderEntryMessage order_entries = 1;\n  string next_page_token = 2;\n",
+		"message UpdateOrderEntryRequest {\n  OrderEntryMessage order_entry = 1;\n  google.protobuf.FieldMask update_mask = 2;\n}",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
+
+func TestProtoBufServicePluralTable(t *testing.T) {
+	gen := newTestProtoBuf(ProtoBufConfig{EnumDir: "enums", MessageDir: "pg"})
+	table := compositeKeyTable()
+	table.Schema = "billing"
+	table.Name = "orders"
+	var buf bytes.Buffer
+	if err := gen.buildService(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import \"pg/billing/OrdersMessage.proto\";\n",
+		"  rpc ListOrders(ListOrdersRequest) returns (ListOrdersResponse);\n",
+		"  repeated OrdersMessage orders = 1;\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+	if strings.Contains(out, "Orderses") || strings.Contains(out, "enums/") {
+		t.Errorf("unexpected name or import\n%s", out)
+	}
+}
+
+func TestProtoBufTypeOverrides(t *testing.T) {
+	gen := newTestProtoBuf(ProtoBufConfig{
+		TypeOverrides: TypeOverrides{
+			"item.email": {
+				Type:        "string",
+				Imports:     []string{"validate/validate.proto"},
+				Annotations: []string{"(validate.rules).string.email = true"},
+			},
+			"tsvector": {Type: "string"},
+		},
+	})
+	table := Table{
+		Name: "item",
+		Columns: []Column{
+			{Name: "email", DataType: "text", NotNull: true},
+			{Name: "search", DataType: "tsvector", NotNull: true},
+		},
+	}
+	var buf bytes.Buffer
+	if err := gen.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import \"validate/validate.proto\";",
+		"  string email = 1 [(validate.rules).string.email = true]; // ",
+		"  string search = 2; // ",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+}
@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -19,6 +19,8 @@ type SphinxConfig struct {
 	Output       string   `json:"output"`
 	Templates    string   `json:"templates"`
 	IgnoreTables []string `json:"ignore_tables"`
+
+	TypeOverrides TypeOverrides `json:"type_overrides"`
 }
 
 type Sphinx struct {
@@ -34,6 +36,9 @@ type SphinxMember struct {
 	Type       string
 	Constraint string
 	Comment    string
+	Nullable   bool
+	Default    string
+	Size       string
 }
 
 type SphinxTypeMember struct {
@@ -81,8 +86,8 @@ func (gen *Sphinx) Build(ins InspectResult) error {
 		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
 			continue
 		}
-		fileName := SnakeToUpperCamel(table.Name) + ".rst"
-		file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), fileName))
+		fileName := filepath.Join(schemaDir(table.Schema), SnakeToUpperCamel(table.Name)+".rst")
+		file, err := createFile(filePathJoinRoot(gen.root, gen.config.Output), fileName)
 		if err != nil {
 			return errors.Wrap(err, "build create file")
 		}
@@ -93,15 +98,19 @@ func (gen *Sphinx) Build(ins InspectResult) error {
 		file.Close()
 	}
 
-	// Build types
-	enumFileName := "enum.rst"
-	file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), enumFileName))
-	defer file.Close()
-	if err != nil {
-		return errors.Wrap(err, "build create file")
-	}
-	if err := gen.buildType(file, gen.ins.Types); err != nil {
-		return errors.Wrap(err, "build write type")
+	// Build types, one enum section per schema
+	schemas, types := typesBySchema(gen.ins.Types)
+	for _, schema := range schemas {
+		enumFileName := filepath.Join(schemaDir(schema), "enum.rst")
+		file, err := createFile(filePathJoinRoot(gen.root, gen.config.Output), enumFileName)
+		if err != nil {
+			return errors.Wrap(err, "build create file")
+		}
+		if err := gen.buildType(file, schema, types[schema]); err != nil {
+			file.Close()
+			return errors.Wrap(err, "build write type")
+		}
+		file.Close()
 	}
 
 	return nil
@@ -111,12 +120,20 @@ func (gen *Sphinx) buildTable(wr io.Writer, table Table) error {
 	return gen.template.ExecuteTemplate(wr, "table", map[string]interface{}{
 		"now":     time.Now().UTC().Format(time.RFC3339),
 		"comment": table.Comment.String,
+		"schema":  table.Schema,
 		"name":    table.Name,
 		"member":  gen.members(table),
 	})
 }
 
 func (gen *Sphinx) members(table Table) []SphinxMember {
+	return docMembers(table, gen.config.TypeOverrides)
+}
+
+// docMembers returns the column rows of a document. It is shared by the
+// document generators so that they show the same information. Types are
+// replaced by overrides.
+func docMembers(table Table, overrides TypeOverrides) []SphinxMember {
 	var ret []SphinxMember
 
 	for _, col := range table.Columns {
@@ -130,6 +147,13 @@ func (gen *Sphinx) members(table Table) []SphinxMember {
 			cons = col.ConstraintSrc.String
 		}
 		dtype := col.DataType
+		if col.BaseType != "" {
+			// size is shown separately
+			dtype = col.BaseType + strings.Repeat("[]", col.ArrayDims)
+		}
+		if o, ok := overrides.find(table, col); ok {
+			dtype = o.Type
+		}
 		if col.Serial {
 			dtype += "(serial)"
 		}
@@ -139,13 +163,30 @@ func (gen *Sphinx) members(table Table) []SphinxMember {
 			Type:       dtype,
 			Constraint: cons,
 			Comment:    strings.Replace(col.Comment.String, "\n", "", -1),
+			Nullable:   !col.NotNull,
+			Default:    col.DefaultValue.String,
+			Size:       docSize(col),
 		}
 		ret = append(ret, m)
 	}
 	return ret
 }
 
-func (gen *Sphinx) buildType(wr io.Writer, types []Type) error {
+// docSize returns the length, precision and scale, or fractional digits of
+// col, such as "255" or "12,4".
+func docSize(col Column) string {
+	switch {
+	case col.Length > 0:
+		return strconv.Itoa(col.Length)
+	case col.Precision > 0:
+		return fmt.Sprintf("%d,%d", col.Precision, col.Scale)
+	case col.TimePrecision.Valid:
+		return strconv.FormatInt(col.TimePrecision.Int64, 10)
+	}
+	return ""
+}
+
+func (gen *Sphinx) buildType(wr io.Writer, schema string, types []Type) error {
 	var members []SphinxTypeMember
 	for _, typ := range types {
 		var vs []string
@@ -162,9 +203,9 @@ func (gen *Sphinx) buildType(wr io.Writer, types []Type) error {
 
 	return gen.template.ExecuteTemplate(wr, "enum", map[string]interface{}{
 		"now":     time.Now().UTC().Format(time.RFC3339),
+		"schema":  schema,
 		"members": members,
 	})
-	return nil
 }
 
 func loadSphinxConfig(root string, raw json.RawMessage) (SphinxConfig, error) {
@@ -176,6 +217,9 @@ func loadSphinxConfig(root string, raw json.RawMessage) (SphinxConfig, error) {
 	if err := DirExists(output); err != nil {
 		return pbc, fmt.Errorf("protobuf output is not exists: %s", pbc.Output)
 	}
+	if err := pbc.TypeOverrides.validate(); err != nil {
+		return pbc, fmt.Errorf("sphinx %s", err)
+	}
 	return pbc, nil
 }
 
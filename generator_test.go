@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -31,3 +33,109 @@ func TestPartContains(t *testing.T) {
 		t.Error("should be true")
 	}
 }
+
+func TestPluralize(t *testing.T) {
+	ff := [][]string{
+		{"orderItem", "orderItems"},
+		{"address", "addresses"},
+		{"company", "companies"},
+		{"day", "days"},
+		{"status", "statuses"},
+		{"box", "boxes"},
+		{"orders", "orders"},
+		{"users", "users"},
+		{"orderItems", "orderItems"},
+	}
+	for _, d := range ff {
+		if actual := pluralize(d[0]); actual != d[1] {
+			t.Errorf("expected %s, actual: %s", d[1], actual)
+		}
+	}
+}
+
+func TestTypeOverrides(t *testing.T) {
+	var overrides TypeOverrides
+	raw := `{
+		"citext": "String",
+		"numeric(*)": {"type": "BigDecimal", "imports": ["java.math.BigDecimal"]},
+		"/^character varying/": "String",
+		"item.ip": {"type": "InetAddress", "annotations": ["@Convert(converter = InetConverter.class)"]}
+	}`
+	if err := json.Unmarshal([]byte(raw), &overrides); err != nil {
+		t.Fatal(err)
+	}
+	if err := overrides.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	table := Table{Schema: "public", Name: "item"}
+	cases := []struct {
+		col      Column
+		expected string
+	}{
+		{Column{Name: "name", DataType: "citext"}, "String"},
+		{Column{Name: "price", DataType: "numeric(10,2)"}, "BigDecimal"},
+		{Column{Name: "price", DataType: "numeric"}, ""},
+		{Column{Name: "code", DataType: "character varying(32)"}, "String"},
+		{Column{Name: "ip", DataType: "inet"}, "InetAddress"},
+		{Column{Name: "ip2", DataType: "inet"}, ""},
+	}
+	for _, c := range cases {
+		o, ok := overrides.find(table, c.col)
+		if ok != (c.expected != "") || o.Type != c.expected {
+			t.Errorf("%s: expected %s, actual: %s", c.col.Name, c.expected, o.Type)
+		}
+	}
+	if o, _ := overrides.find(table, Column{DataType: "numeric(1,0)"}); len(o.Imports) != 1 {
+		t.Errorf("imports are not loaded: %v", o)
+	}
+
+	if err := (TypeOverrides{"/[/": {Type: "x"}}).validate(); err == nil {
+		t.Error("invalid regexp should be an error")
+	}
+}
+
+func TestCheckNames(t *testing.T) {
+	ins := InspectResult{
+		Tables: []Table{
+			{Schema: "billing", Name: "invoice"},
+			{Schema: "audit", Name: "invoice"},
+		},
+		Types: []Type{
+			{Schema: "public", Name: "status"},
+		},
+	}
+	err := checkNames(ins, nil, SnakeToUpperCamel)
+	if err == nil {
+		t.Fatal("error expected")
+	}
+	expected := "table billing.invoice and table audit.invoice are both named Invoice"
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("%q is not found in %s", expected, err)
+	}
+
+	if err := checkNames(ins, []string{"^invoice$"}, SnakeToUpperCamel); err != nil {
+		t.Error(err)
+	}
+
+	ins.Tables = append(ins.Tables[:1], Table{Schema: "public", Name: "status"})
+	if err := checkNames(ins, nil, SnakeToUpperCamel); err == nil {
+		t.Error("error expected on a table and a type of the same name")
+	}
+}
+
+func TestEnumConstant(t *testing.T) {
+	ff := [][]string{
+		{"active", "ACTIVE"},
+		{"in_review", "IN_REVIEW"},
+		{"in-progress", "IN_PROGRESS"},
+		{"on hold", "ON_HOLD"},
+		{"2fa", "VALUE_2FA"},
+		{"1.5", "VALUE_1_5"},
+	}
+	for _, d := range ff {
+		if actual := enumConstant(d[0]); actual != d[1] {
+			t.Errorf("expected %s, actual: %s", d[1], actual)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type TypeScriptConfig struct {
+	Output       string   `json:"output"`
+	Templates    string   `json:"templates"`
+	IgnoreTables []string `json:"ignore_tables"`
+	// EnumStyle is "union" (default) for string literal unions or "enum".
+	EnumStyle string `json:"enum_style"`
+	// BigIntType and NumericType are "number" (default) or "string".
+	BigIntType  string `json:"bigint_type"`
+	NumericType string `json:"numeric_type"`
+	// TimestampType is "string" (default) or "Date".
+	TimestampType string `json:"timestamp_type"`
+	// KeepSnakeCase uses column names as keys instead of lowerCamel.
+	KeepSnakeCase bool `json:"keep_snake_case"`
+
+	TypeOverrides TypeOverrides `json:"type_overrides"`
+}
+
+type TypeScript struct {
+	db       *sql.DB
+	config   TypeScriptConfig
+	ins      InspectResult
+	template *template.Template
+	root     string
+}
+
+type TypeScriptMember struct {
+	Name    string
+	Type    string
+	Comment string
+}
+
+type TypeScriptTypeMember struct {
+	Name    string
+	Comment string
+	Type    Type
+	Values  []TypeScriptEnumValue
+}
+
+type TypeScriptEnumValue struct {
+	Name  string
+	Value string
+}
+
+const TypeScriptTypeName = "typescript"
+
+const (
+	TypeScriptEnumStyleUnion = "union"
+	TypeScriptEnumStyleEnum  = "enum"
+)
+
+func NewTypeScript(db *sql.DB, root string, raw json.RawMessage) (Generator, error) {
+	config, err := loadTypeScriptConfig(root, raw)
+	if err != nil {
+		return nil, err
+	}
+	ret := TypeScript{
+		db:     db,
+		config: config,
+		root:   root,
+	}
+
+	return &ret, nil
+}
+
+func (gen *TypeScript) GetType() string {
+	return TypeScriptTypeName
+}
+
+func (gen *TypeScript) Build(ins InspectResult) error {
+	log.Printf("output: %s", filePathJoinRoot(gen.root, gen.config.Output))
+	log.Printf("templates: %s", filePathJoinRoot(gen.root, gen.config.Templates))
+	gen.ins = ins
+
+	// Load templates
+	funcs := template.FuncMap{
+		"join": strings.Join,
+	}
+	tdir := filepath.Join(filePathJoinRoot(gen.root, gen.config.Templates), "*.tmpl")
+	t := template.Must(template.New("").Funcs(funcs).ParseGlob(tdir))
+	gen.template = t
+	if err := checkNames(gen.ins, gen.config.IgnoreTables, SnakeToUpperCamel); err != nil {
+		return errors.Wrap(err, "build")
+	}
+
+	var modules []string
+
+	// Build tables
+	for _, table := range gen.ins.Tables {
+		if partContainsRegex(gen.config.IgnoreTables, table.Name) {
+			continue
+		}
+		module := SnakeToUpperCamel(table.Name)
+		file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), module+".ts"))
+		if err != nil {
+			return errors.Wrap(err, "build create file")
+		}
+		if err := gen.buildTable(file, table); err != nil {
+			file.Close()
+			return errors.Wrap(err, "build write table")
+		}
+		file.Close()
+		modules = append(modules, module)
+	}
+
+	// Build types
+	file, err := os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), "enums.ts"))
+	if err != nil {
+		return errors.Wrap(err, "build create file")
+	}
+	if err := gen.buildType(file, gen.ins.Types); err != nil {
+		file.Close()
+		return errors.Wrap(err, "build write type")
+	}
+	file.Close()
+	modules = append(modules, "enums")
+
+	// Build index
+	file, err = os.Create(filepath.Join(filePathJoinRoot(gen.root, gen.config.Output), "index.ts"))
+	if err != nil {
+		return errors.Wrap(err, "build create file")
+	}
+	defer file.Close()
+	if err := gen.template.ExecuteTemplate(file, "index", map[string]interface{}{
+		"modules": modules,
+	}); err != nil {
+		return errors.Wrap(err, "build write index")
+	}
+
+	return nil
+}
+
+func (gen *TypeScript) buildTable(wr io.Writer, table Table) error {
+	return gen.template.ExecuteTemplate(wr, "interface", map[string]interface{}{
+		"comment": strings.Replace(table.Comment.String, "\n", " ", -1),
+		"table":   table,
+		"name":    SnakeToUpperCamel(table.Name),
+		"member":  gen.members(table),
+		"enums":   gen.enums(table),
+		"imports": gen.imports(table),
+	})
+}
+
+func (gen *TypeScript) members(table Table) []TypeScriptMember {
+	var ret []TypeScriptMember
+
+	for _, col := range table.Columns {
+		name := SnakeToLowerCamel(col.Name)
+		if gen.config.KeepSnakeCase {
+			name = col.Name
+		}
+		m := TypeScriptMember{
+			Name:    name,
+			Comment: strings.Replace(col.Comment.String, "\n", " ", -1),
+		}
+		if o, ok := gen.config.TypeOverrides.find(table, col); ok {
+			// annotations are JSDoc tags
+			m.Type = o.Type
+			m.Comment = strings.TrimSpace(strings.Join(append([]string{m.Comment}, o.Annotations...), " "))
+			ret = append(ret, m)
+			continue
+		}
+		m.Type = gen.convertType(col)
+		if col.Array {
+			m.Type = m.Type + "[]"
+		}
+		if !col.NotNull {
+			m.Type = m.Type + " | null"
+		}
+		ret = append(ret, m)
+	}
+	return ret
+}
+
+// imports returns the import statements of type_overrides used by table.
+func (gen *TypeScript) imports(table Table) []string {
+	var ret []string
+	for _, col := range table.Columns {
+		o, _ := gen.config.TypeOverrides.find(table, col)
+		for _, i := range o.Imports {
+			if !contains(ret, i) {
+				ret = append(ret, i)
+			}
+		}
+	}
+	return ret
+}
+
+// enums returns enum type names used by table.
+func (gen *TypeScript) enums(table Table) []string {
+	var ret []string
+	for _, col := range table.Columns {
+		if _, ok := gen.config.TypeOverrides.find(table, col); ok {
+			continue
+		}
+		typ, err := gen.ins.FindType(strings.Replace(col.DataType, "[]", "", 1))
+		if err != nil {
+			continue
+		}
+		name := SnakeToUpperCamel(typ.Name)
+		if !contains(ret, name) {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+func (gen *TypeScript) buildType(wr io.Writer, types []Type) error {
+	var members []TypeScriptTypeMember
+	for _, typ := range types {
+		var vs []TypeScriptEnumValue
+		for _, val := range typ.Values {
+			vs = append(vs, TypeScriptEnumValue{
+				Name:  typeScriptEnumMember(val),
+				Value: typeScriptString(val),
+			})
+		}
+		m := TypeScriptTypeMember{
+			Name:    SnakeToUpperCamel(typ.Name),
+			Comment: strings.Replace(typ.Comment.String, "\n", " ", -1),
+			Type:    typ,
+			Values:  vs,
+		}
+		members = append(members, m)
+	}
+
+	return gen.template.ExecuteTemplate(wr, "enum", map[string]interface{}{
+		"enum_style_enum": gen.config.EnumStyle == TypeScriptEnumStyleEnum,
+		"members":         members,
+	})
+}
+
+// typeScriptEnumMember returns an enum member name of val. Runes which are
+// not allowed in identifiers become "_", and names which start with a digit
+// are prefixed with "Value".
+func typeScriptEnumMember(val string) string {
+	name := SnakeToUpperCamel(strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, val))
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "Value" + name
+	}
+	return name
+}
+
+// typeScriptString escapes s for a single quoted string literal.
+func typeScriptString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+func (gen *TypeScript) convertType(col Column) string {
+	t := strings.Replace(col.DataType, "[]", "", 1)
+
+	if pt, ok := lookupPgType(t); ok {
+		switch pt {
+		case PgInt16, PgInt32, PgFloat32, PgFloat64:
+			return "number"
+		case PgInt64:
+			return gen.config.BigIntType
+		case PgNumeric:
+			return gen.config.NumericType
+		case PgBool:
+			return "boolean"
+		case PgDate, PgTimestamp, PgTimestampTZ:
+			return gen.config.TimestampType
+		case PgJSON:
+			return "unknown"
+		}
+		// text representation of Postgres
+		return "string"
+	}
+	typ, err := gen.ins.FindType(t)
+	if err == nil {
+		return SnakeToUpperCamel(typ.Name)
+	}
+	warnUnknownType(TypeScriptTypeName, t, "unknown")
+	return "unknown"
+}
+
+func loadTypeScriptConfig(root string, raw json.RawMessage) (TypeScriptConfig, error) {
+	var tc TypeScriptConfig
+	if err := json.Unmarshal(raw, &tc); err != nil {
+		return tc, fmt.Errorf("typescript config error: %s", err)
+	}
+	output := filePathJoinRoot(root, tc.Output)
+	if err := DirExists(output); err != nil {
+		return tc, fmt.Errorf("typescript output is not exists: %s", tc.Output)
+	}
+	if tc.EnumStyle == "" {
+		tc.EnumStyle = TypeScriptEnumStyleUnion
+	}
+	if tc.BigIntType == "" {
+		tc.BigIntType = "number"
+	}
+	if tc.NumericType == "" {
+		tc.NumericType = "number"
+	}
+	if tc.TimestampType == "" {
+		tc.TimestampType = "string"
+	}
+	if !contains([]string{TypeScriptEnumStyleUnion, TypeScriptEnumStyleEnum}, tc.EnumStyle) {
+		return tc, fmt.Errorf("typescript unknown enum_style: %s", tc.EnumStyle)
+	}
+	for _, t := range []string{tc.BigIntType, tc.NumericType} {
+		if t != "number" && t != "string" {
+			return tc, fmt.Errorf("typescript bigint_type and numeric_type must be number or string: %s", t)
+		}
+	}
+	if tc.TimestampType != "string" && tc.TimestampType != "Date" {
+		return tc, fmt.Errorf("typescript timestamp_type must be string or Date: %s", tc.TimestampType)
+	}
+	if err := tc.TypeOverrides.validate(); err != nil {
+		return tc, fmt.Errorf("typescript %s", err)
+	}
+	return tc, nil
+}
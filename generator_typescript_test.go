@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func newTestTypeScript(config TypeScriptConfig) TypeScript {
+	funcs := template.FuncMap{
+		"join": strings.Join,
+	}
+	return TypeScript{
+		config: config,
+		ins: InspectResult{
+			Types: []Type{{Schema: "public", Name: "order_status", Values: []string{"open", "in_review"}}},
+		},
+		template: template.Must(template.New("").Funcs(funcs).ParseGlob("templates/typescript/*.tmpl")),
+	}
+}
+
+func TestTypeScriptBuildTable(t *testing.T) {
+	table := Table{
+		Schema: "public",
+		Name:   "purchase_order",
+		Columns: []Column{
+			{Name: "id", DataType: "bigint", NotNull: true},
+			{Name: "total_amount", DataType: "numeric(12,4)"},
+			{Name: "status", DataType: "order_status", NotNull: true},
+			{Name: "created_at", DataType: "timestamp with time zone", NotNull: true},
+			{Name: "tags", DataType: "text[]", Array: true},
+		},
+	}
+	ts := newTestTypeScript(TypeScriptConfig{BigIntType: "string", NumericType: "string", TimestampType: "Date"})
+
+	var buf bytes.Buffer
+	if err := ts.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"import { OrderStatus } from './enums';",
+		"export interface PurchaseOrder {",
+		"  id: string;",
+		"  totalAmount: string | null;",
+		"  status: OrderStatus;",
+		"  createdAt: Date;",
+		"  tags: string[] | null;",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q is not found in\n%s", expected, out)
+		}
+	}
+
+	ts.config.KeepSnakeCase = true
+	buf.Reset()
+	if err := ts.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "  total_amount: string | null;") {
+		t.Errorf("snake case key is not found in\n%s", buf.String())
+	}
+}
+
+func TestTypeScriptBuildType(t *testing.T) {
+	ts := newTestTypeScript(TypeScriptConfig{EnumStyle: TypeScriptEnumStyleUnion})
+	var buf bytes.Buffer
+	if err := ts.buildType(&buf, ts.ins.Types); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "export type OrderStatus = 'open' | 'in_review';"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q is not found in\n%s", expected, buf.String())
+	}
+
+	ts.config.EnumStyle = TypeScriptEnumStyleEnum
+	buf.Reset()
+	if err := ts.buildType(&buf, ts.ins.Types); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "export enum OrderStatus {\n  Open = 'open',\n  InReview = 'in_review',\n}"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q is not found in\n%s", expected, buf.String())
+	}
+
+	types := []Type{
+		{Schema: "public", Name: "task_state", Values: []string{"in-progress", "on hold", "2fa", `a\'b`}},
+		{Schema: "public", Name: "empty"},
+	}
+	buf.Reset()
+	if err := ts.buildType(&buf, types); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "export enum TaskState {\n  InProgress = 'in-progress',\n  OnHold = 'on hold',\n  Value2fa = '2fa',\n  AB = 'a\\\\\\'b',\n}"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q is not found in\n%s", expected, buf.String())
+	}
+
+	ts.config.EnumStyle = TypeScriptEnumStyleUnion
+	buf.Reset()
+	if err := ts.buildType(&buf, types); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "export type Empty = never;"; !strings.Contains(buf.String(), expected) {
+		t.Errorf("%q is not found in\n%s", expected, buf.String())
+	}
+}
+
+func TestTypeScriptTypeOverrides(t *testing.T) {
+	table := Table{
+		Schema: "public",
+		Name:   "purchase_order",
+		Columns: []Column{
+			{Name: "status", DataType: "order_status", NotNull: true},
+			{Name: "total_amount", DataType: "numeric(12,4)"},
+		},
+	}
+	ts := newTestTypeScript(TypeScriptConfig{
+		NumericType:   "number",
+		TimestampType: "string",
+		TypeOverrides: TypeOverrides{
+			"numeric(*)": {
+				Type:        "Decimal | null",
+				Imports:     []string{"import Decimal from 'decimal.js';"},
+				Annotations: []string{"@precision 12"},
+			},
+			"purchase_order.status": {Type: "string"},
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := ts.buildTable(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	expected := `// Generated by pg2any. DO NOT EDIT THIS FILE
+
+import Decimal from 'decimal.js';
+
+/**
+ * public.purchase_order
+ */
+export interface PurchaseOrder {
+  status: string;
+  /** @precision 12 */
+  totalAmount: Decimal | null;
+}
+`
+	if out != expected {
+		t.Errorf("expected %s, actual: %s", expected, out)
+	}
+}
@@ -3,9 +3,10 @@ package main
 import (
 	"database/sql"
 	"fmt"
-	"strings"
 	"regexp"
+	"strings"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +24,23 @@ type Table struct {
 	PrimaryKeys []Column
 	Columns     []Column
 	Indexs      []Index
+	ForeignKeys []ForeignKey
+}
+
+// ForeignKey is a foreign key constraint of a table. Schema and Table are
+// the referencing (owning) side.
+type ForeignKey struct {
+	Name              string   // constraint name
+	Schema            string   // schema of the referencing table
+	Table             string   // referencing table
+	Columns           []string // referencing columns, in constraint order
+	RefSchema         string   // schema of the referenced table
+	RefTable          string   // referenced table
+	RefColumns        []string // referenced columns, in constraint order
+	OnDelete          string   // NO ACTION, RESTRICT, CASCADE, SET NULL or SET DEFAULT
+	OnUpdate          string   // NO ACTION, RESTRICT, CASCADE, SET NULL or SET DEFAULT
+	Deferrable        bool
+	InitiallyDeferred bool
 }
 
 type Column struct {
@@ -39,19 +57,39 @@ type Column struct {
 	Array         bool
 	Constraint    sql.NullString
 	ConstraintSrc sql.NullString
-	ForignTable   sql.NullString
+	ForignTable   sql.NullString // referenced table, see Table.ForeignKeys for details
 	SerialSrc     sql.NullString
 	IndexDef      sql.NullString
+	Checks        []string // definitions of CHECK constraints on the column, see parseChecks
+
+	// Parsed from DataType, see parseDataType.
+	BaseType      string        // type name without modifiers and array, such as "character varying"
+	ElementType   string        // DataType without array, such as "numeric(12,4)"
+	ArrayDims     int           // array dimensions, 0 if not an array
+	Length        int           // max length of character and bit types, 0 if not limited
+	Precision     int           // precision of numeric, 0 if not specified
+	Scale         int           // scale of numeric
+	TimePrecision sql.NullInt64 // fractional digits of time, timestamp and interval
 }
 
 type Type struct {
 	DataType string
+	Schema   string
 	Name     string
 	Comment  sql.NullString
 	NotNull  bool
 	Values   []string
 }
 
+// Domain is a domain type. Columns of a domain are inspected as its base
+// type, see resolveDomain.
+type Domain struct {
+	Schema   string
+	Name     string
+	BaseType string // format_type of the base type, such as "character varying(20)"
+	NotNull  bool
+}
+
 type Index struct {
 	DataType string
 	Name     string
@@ -59,34 +97,146 @@ type Index struct {
 	Comment  sql.NullString
 }
 
+// FindType finds a type by name. name may be schema qualified such as
+// "billing.status", which format_type returns for types outside of the
+// search_path. A bare name is a type of the default schema, or the only type
+// of that name. It is not found if several schemas other than the default
+// one have it, rather than picking one of them.
 func (ins InspectResult) FindType(name string) (Type, error) {
+	var found []Type
 	for _, typ := range ins.Types {
+		if typ.Schema+"."+typ.Name == name {
+			return typ, nil
+		}
 		if typ.Name == name {
+			found = append(found, typ)
+		}
+	}
+	for _, typ := range found {
+		if schemaDir(typ.Schema) == "" {
 			return typ, nil
 		}
 	}
-	return Type{}, fmt.Errorf("not found")
+	switch len(found) {
+	case 0:
+		return Type{}, fmt.Errorf("not found")
+	case 1:
+		return found[0], nil
+	}
+	return Type{}, fmt.Errorf("%s is ambiguous", name)
 }
 
-func Inspect(db *sql.DB) (InspectResult, error) {
+// FindTable finds a table by schema and name.
+func (ins InspectResult) FindTable(schema, name string) (Table, error) {
+	for _, table := range ins.Tables {
+		if table.Schema == schema && table.Name == name {
+			return table, nil
+		}
+	}
+	return Table{}, fmt.Errorf("not found")
+}
+
+// ReferencedBy returns foreign keys of all tables which reference table.
+func (ins InspectResult) ReferencedBy(table Table) []ForeignKey {
+	var ret []ForeignKey
+	for _, t := range ins.Tables {
+		for _, fk := range t.ForeignKeys {
+			if fk.RefSchema == table.Schema && fk.RefTable == table.Name {
+				ret = append(ret, fk)
+			}
+		}
+	}
+	return ret
+}
+
+// FindForeignKey finds a foreign key which includes column.
+func (t Table) FindForeignKey(column string) (ForeignKey, bool) {
+	for _, fk := range t.ForeignKeys {
+		if contains(fk.Columns, column) {
+			return fk, true
+		}
+	}
+	return ForeignKey{}, false
+}
+
+// systemSchemas are never inspected.
+var systemSchemas = []string{"^pg_", "^information_schema$"}
+
+func Inspect(db *sql.DB, schemas []string) (InspectResult, error) {
 	var ret InspectResult
 
-	tables, err := getTables(db, "public")
+	names, err := getSchemas(db, schemas)
 	if err != nil {
 		return ret, errors.Wrap(err, "Inspect")
 	}
-	ret.Tables = tables
-
-	types, err := getTypes(db)
+	// domains of any schema, which may be created by an extension
+	domains, err := getDomains(db)
 	if err != nil {
 		return ret, errors.Wrap(err, "Inspect")
 	}
-	ret.Types = types
+
+	for _, schema := range names {
+		tables, err := getTables(db, schema, domains)
+		if err != nil {
+			return ret, errors.Wrap(err, "Inspect")
+		}
+		ret.Tables = append(ret.Tables, tables...)
+
+		types, err := getTypes(db, schema)
+		if err != nil {
+			return ret, errors.Wrap(err, "Inspect")
+		}
+		ret.Types = append(ret.Types, types...)
+	}
 
 	return ret, nil
 }
 
-func getTables(db *sql.DB, schema string) ([]Table, error) {
+// matchSchema reports whether schema is selected by patterns. Patterns are
+// regular expressions, and a pattern prefixed with "!" excludes matched
+// schemas. Excludes always win. If no include pattern is given, every
+// schema is included.
+func matchSchema(patterns []string, schema string) bool {
+	if partContainsRegex(systemSchemas, schema) {
+		return false
+	}
+	var includes, excludes []string
+	for _, p := range patterns {
+		if strings.HasPrefix(p, "!") {
+			excludes = append(excludes, strings.TrimPrefix(p, "!"))
+		} else {
+			includes = append(includes, p)
+		}
+	}
+	if partContainsRegex(excludes, schema) {
+		return false
+	}
+	if len(includes) == 0 {
+		return true
+	}
+	return partContainsRegex(includes, schema)
+}
+
+func getSchemas(db *sql.DB, patterns []string) ([]string, error) {
+	q := `SELECT nspname FROM pg_namespace ORDER BY nspname`
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, errors.Wrap(err, "schema query")
+	}
+	var ret []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "schema scan")
+		}
+		if matchSchema(patterns, name) {
+			ret = append(ret, name)
+		}
+	}
+	return ret, nil
+}
+
+func getTables(db *sql.DB, schema string, domains []Domain) ([]Table, error) {
 	// https://github.com/achiku/dgw/blob/master/dgw.go
 	q := `SELECT
 c.relkind AS type,
@@ -111,11 +261,20 @@ ORDER BY c.relname
 			return nil, errors.Wrap(err, "failed to scan of "+t.Name)
 		}
 		t.Indexs, err = getUniqueIndexes(db, schema, t.Name)
-		cols, err := getColumns(db, schema, t.Name, false)
+		cols, err := getColumns(db, schema, t.Name, false, domains)
 		if err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("failed to get columns of %s", t.Name))
 		}
 		t.Columns = cols
+		t.PrimaryKeys, err = getPrimaryKeys(db, schema, t.Name, cols)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to get primary keys of %s", t.Name))
+		}
+		fks, err := getForeignKeys(db, schema, t.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to get foreign keys of %s", t.Name))
+		}
+		t.ForeignKeys = fks
 		tbs = append(tbs, t)
 	}
 	return tbs, nil
@@ -160,15 +319,122 @@ ORDER  BY i.indisprimary DESC,
 		var uniqConstraintColumns []Column
 		// loop: column
 		for _, s := range strings.Split(reg.FindStringSubmatch(indexdef)[1], ",") {
-			uniqConstraintColumns = append(uniqConstraintColumns, Column { Name: strings.TrimSpace(s) })
+			uniqConstraintColumns = append(uniqConstraintColumns, Column{Name: strings.TrimSpace(s)})
 		}
-		indexes = append(indexes, Index {Columns: uniqConstraintColumns})
+		indexes = append(indexes, Index{Columns: uniqConstraintColumns})
 	}
 	return indexes, nil
 }
 
+// getPrimaryKeys returns the primary key columns in constraint order.
+func getPrimaryKeys(db *sql.DB, schema, table string, cols []Column) ([]Column, error) {
+	const sqlstr = `SELECT
+a.attname
+FROM pg_constraint ct
+JOIN ONLY pg_class c ON c.oid = ct.conrelid
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+CROSS JOIN LATERAL unnest(ct.conkey) WITH ORDINALITY AS k(attnum, ord)
+JOIN pg_attribute a ON a.attrelid = ct.conrelid AND a.attnum = k.attnum
+WHERE ct.contype = 'p' AND n.nspname = $1 AND c.relname = $2
+ORDER BY k.ord`
+	q, err := db.Query(sqlstr, schema, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "primary keys query")
+	}
+	var ret []Column
+	for q.Next() {
+		var name string
+		if err := q.Scan(&name); err != nil {
+			return nil, errors.Wrap(err, "primary keys scan")
+		}
+		for _, col := range cols {
+			if col.Name == name {
+				ret = append(ret, col)
+			}
+		}
+	}
+	return ret, nil
+}
+
+func getForeignKeys(db *sql.DB, schema, table string) ([]ForeignKey, error) {
+	const sqlstr = `SELECT
+ct.conname,
+ARRAY(
+  SELECT a.attname FROM unnest(ct.conkey) WITH ORDINALITY AS k(attnum, ord)
+  JOIN pg_attribute a ON a.attrelid = ct.conrelid AND a.attnum = k.attnum
+  ORDER BY k.ord
+)::text[],
+rn.nspname,
+rc.relname,
+ARRAY(
+  SELECT a.attname FROM unnest(ct.confkey) WITH ORDINALITY AS k(attnum, ord)
+  JOIN pg_attribute a ON a.attrelid = ct.confrelid AND a.attnum = k.attnum
+  ORDER BY k.ord
+)::text[],
+ct.confdeltype,
+ct.confupdtype,
+ct.condeferrable,
+ct.condeferred
+FROM pg_constraint ct
+JOIN ONLY pg_class c ON c.oid = ct.conrelid
+JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
+JOIN ONLY pg_class rc ON rc.oid = ct.confrelid
+JOIN ONLY pg_namespace rn ON rn.oid = rc.relnamespace
+WHERE ct.contype = 'f' AND n.nspname = $1 AND c.relname = $2
+ORDER BY ct.conname`
+	q, err := db.Query(sqlstr, schema, table)
+	if err != nil {
+		return nil, errors.Wrap(err, "foreign keys query")
+	}
+	var ret []ForeignKey
+	for q.Next() {
+		fk := ForeignKey{
+			Schema: schema,
+			Table:  table,
+		}
+		var cols, refCols pq.StringArray
+		var onDelete, onUpdate string
+		err = q.Scan(
+			&fk.Name,
+			&cols,
+			&fk.RefSchema,
+			&fk.RefTable,
+			&refCols,
+			&onDelete,
+			&onUpdate,
+			&fk.Deferrable,
+			&fk.InitiallyDeferred,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "foreign keys scan")
+		}
+		fk.Columns = []string(cols)
+		fk.RefColumns = []string(refCols)
+		fk.OnDelete = foreignKeyAction(onDelete)
+		fk.OnUpdate = foreignKeyAction(onUpdate)
+		ret = append(ret, fk)
+	}
+	return ret, nil
+}
+
+// foreignKeyAction converts pg_constraint.confdeltype and confupdtype to
+// the SQL keyword.
+func foreignKeyAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	default:
+		return "NO ACTION"
+	}
+}
 
-func getColumns(db *sql.DB, schema, table string, sys bool) ([]Column, error) {
+func getColumns(db *sql.DB, schema, table string, sys bool, domains []Domain) ([]Column, error) {
 	// https://github.com/xo/xo/blob/master/models/column.xo.go#L21
 	const sqlstr = `SELECT
 a.attnum,
@@ -180,13 +446,14 @@ COALESCE(pg_get_expr(ad.adbin, ad.adrelid), ''),
 ct.contype,
 pg_catalog.pg_get_constraintdef(ct.oid, true),
 cc.relname,
-pg_get_serial_sequence($2, a.attname)
+pg_get_serial_sequence(quote_ident($1::text) || '.' || quote_ident($2::text), a.attname),
+a.attndims
 FROM pg_attribute a
 JOIN ONLY pg_class c ON c.oid = a.attrelid
 JOIN ONLY pg_namespace n ON n.oid = c.relnamespace
 LEFT JOIN pg_constraint ct ON ct.conrelid = c.oid AND a.attnum = ANY(ct.conkey)
 LEFT JOIN pg_attrdef ad ON ad.adrelid = c.oid AND ad.adnum = a.attnum
-LEFT JOIN pg_class cc ON cc.oid = ct.conrelid
+LEFT JOIN pg_class cc ON cc.oid = ct.confrelid
 WHERE a.attisdropped = false AND n.nspname = $1 AND c.relname = $2 AND ($3 OR a.attnum > 0)
 ORDER BY a.attnum`
 	q, err := db.Query(sqlstr, schema, table, sys)
@@ -209,6 +476,7 @@ ORDER BY a.attnum`
 			&c.ConstraintSrc,
 			&c.ForignTable,
 			&c.SerialSrc,
+			&c.ArrayDims,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "columns scan")
@@ -216,15 +484,19 @@ ORDER BY a.attnum`
 		switch c.Constraint.String {
 		case "p":
 			c.PrimaryKey = true
-		//case "u":
-		//	c.Unique = true
+			//case "u":
+			//	c.Unique = true
+		case "c":
+			c.Checks = []string{c.ConstraintSrc.String}
 		}
 		if c.SerialSrc.Valid {
 			c.Serial = true
 		}
+		resolveDomain(&c, domains)
 		if strings.HasSuffix(c.DataType, "[]") {
 			c.Array = true
 		}
+		parseDataType(&c)
 
 		o, exists := tmp[c.Name]
 		if !exists {
@@ -232,9 +504,12 @@ ORDER BY a.attnum`
 		} else {
 			o.PrimaryKey = o.PrimaryKey || c.PrimaryKey
 			o.Unique = o.Unique || c.Unique
-			o.ForignTable = c.ForignTable
+			if c.ForignTable.Valid {
+				o.ForignTable = c.ForignTable
+			}
 			o.Serial = c.Serial
 			o.ConstraintSrc = c.ConstraintSrc
+			o.Checks = append(o.Checks, c.Checks...)
 		}
 
 		tmp[c.Name] = o
@@ -251,32 +526,107 @@ ORDER BY a.attnum`
 	return ret, nil
 }
 
-func getTypes(db *sql.DB) ([]Type, error) {
-	q := `
+// domainsQuery selects the domains of all schemas.
+const domainsQuery = `
 SELECT
+n.nspname,
+t.typname,
+format_type(t.typbasetype, t.typtypmod),
+t.typnotnull
+FROM        pg_type t
+JOIN        pg_catalog.pg_namespace n ON n.oid = t.typnamespace
+WHERE       t.typtype = 'd'
+`
+
+func getDomains(db *sql.DB) ([]Domain, error) {
+	rows, err := db.Query(domainsQuery)
+	if err != nil {
+		return nil, errors.Wrap(err, "domain query")
+	}
+	var ret []Domain
+	for rows.Next() {
+		var d Domain
+		if err := rows.Scan(&d.Schema, &d.Name, &d.BaseType, &d.NotNull); err != nil {
+			return nil, errors.Wrap(err, "domain scan")
+		}
+		ret = append(ret, d)
+	}
+	return ret, nil
+}
+
+// resolveDomain replaces the data type of c by the base type if it is a
+// domain, so that generators map it as the base type. A domain over a domain
+// is resolved up to the base type. NOT NULL of a domain makes the column NOT
+// NULL.
+func resolveDomain(c *Column, domains []Domain) {
+	// bounded, in case of a broken catalog
+	for i := 0; i < len(domains); i++ {
+		array := strings.HasSuffix(c.DataType, "[]")
+		d, ok := findDomain(domains, strings.TrimSuffix(c.DataType, "[]"))
+		if !ok {
+			return
+		}
+		c.DataType = d.BaseType
+		if array {
+			c.DataType += "[]"
+		} else if d.NotNull {
+			c.NotNull = true
+		}
+	}
+}
+
+// findDomain finds a domain by name in the same way as FindType.
+func findDomain(domains []Domain, name string) (Domain, bool) {
+	var found []Domain
+	for _, d := range domains {
+		if d.Schema+"."+d.Name == name {
+			return d, true
+		}
+		if d.Name == name {
+			found = append(found, d)
+		}
+	}
+	for _, d := range found {
+		if schemaDir(d.Schema) == "" {
+			return d, true
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	return Domain{}, false
+}
+
+// typesQuery selects the enum types of a schema. Composite and base types
+// such as citext are not included, which are mapped to a fallback type.
+// Domains are resolved to their base type by resolveDomain.
+const typesQuery = `
+SELECT
+n.nspname,
 t.typname as type,
 obj_description(t.oid),
 t.typnotnull
 FROM        pg_type t
 LEFT JOIN   pg_catalog.pg_namespace n ON n.oid = t.typnamespace
-WHERE       (t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid))
-AND     NOT EXISTS(SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid)
-AND     n.nspname NOT IN ('pg_catalog', 'information_schema')
+WHERE       t.typtype = 'e'
+AND     n.nspname = $1
 ORDER BY type
 `
 
-	rows, err := db.Query(q)
+// getTypes returns the enum types of schema.
+func getTypes(db *sql.DB, schema string) ([]Type, error) {
+	rows, err := db.Query(typesQuery, schema)
 	if err != nil {
 		return nil, errors.Wrap(err, "type query")
 	}
 	var typs []Type
 	for rows.Next() {
 		var t Type
-		if err := rows.Scan(&t.Name, &t.Comment, &t.NotNull); err != nil {
+		if err := rows.Scan(&t.Schema, &t.Name, &t.Comment, &t.NotNull); err != nil {
 			return nil, errors.Wrap(err, "type scan")
 		}
 
-		values, err := getEnum(db, t.Name)
+		values, err := getEnum(db, t.Schema, t.Name)
 		if err != nil {
 			return nil, errors.Wrap(err, "get Enum")
 		}
@@ -286,17 +636,20 @@ ORDER BY type
 	return typs, nil
 }
 
-func getEnum(db *sql.DB, typName string) ([]string, error) {
+func getEnum(db *sql.DB, schema, typName string) ([]string, error) {
 	q := `
 SELECT pg_enum.enumlabel AS enumlabel
 FROM pg_type
+JOIN pg_namespace
+     ON pg_namespace.oid = pg_type.typnamespace
 JOIN pg_enum
      ON pg_enum.enumtypid = pg_type.oid
 WHERE
-     pg_type.typname = $1
+     pg_namespace.nspname = $1
+     AND pg_type.typname = $2
 ORDER BY pg_enum.enumsortorder
 `
-	rows, err := db.Query(q, typName)
+	rows, err := db.Query(q, schema, typName)
 	if err != nil {
 		return nil, errors.Wrap(err, "enum query")
 	}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchSchema(t *testing.T) {
+	ff := []struct {
+		patterns []string
+		schema   string
+		expected bool
+	}{
+		{nil, "public", true},
+		{nil, "billing", true},
+		{nil, "pg_catalog", false},
+		{nil, "pg_toast", false},
+		{nil, "information_schema", false},
+		{[]string{"^public$", "^billing$"}, "billing", true},
+		{[]string{"^public$", "^billing$"}, "audit", false},
+		{[]string{"!^audit$"}, "audit", false},
+		{[]string{"!^audit$"}, "catalog", true},
+		{[]string{"^(audit|billing)$", "!^audit$"}, "audit", false},
+		{[]string{"^pg_catalog$"}, "pg_catalog", false},
+	}
+	for _, d := range ff {
+		if actual := matchSchema(d.patterns, d.schema); actual != d.expected {
+			t.Errorf("%v %s: expected %t, actual: %t", d.patterns, d.schema, d.expected, actual)
+		}
+	}
+}
+
+func TestFindForeignKey(t *testing.T) {
+	table := Table{
+		Schema: "billing",
+		Name:   "invoice_line",
+		ForeignKeys: []ForeignKey{
+			{
+				Name:       "invoice_line_invoice_fkey",
+				Schema:     "billing",
+				Table:      "invoice_line",
+				Columns:    []string{"invoice_id", "invoice_year"},
+				RefSchema:  "billing",
+				RefTable:   "invoice",
+				RefColumns: []string{"id", "year"},
+			},
+		},
+	}
+	fk, ok := table.FindForeignKey("invoice_year")
+	if !ok || fk.Name != "invoice_line_invoice_fkey" {
+		t.Errorf("foreign key not found: %v", fk)
+	}
+	if _, ok := table.FindForeignKey("amount"); ok {
+		t.Error("should not be found")
+	}
+
+	ins := InspectResult{Tables: []Table{table, {Schema: "billing", Name: "invoice"}}}
+	ref, _ := ins.FindTable("billing", "invoice")
+	if fks := ins.ReferencedBy(ref); len(fks) != 1 || fks[0].Table != "invoice_line" {
+		t.Errorf("unexpected referenced by: %v", fks)
+	}
+}
+
+func TestForeignKeyAction(t *testing.T) {
+	ff := [][]string{
+		{"a", "NO ACTION"},
+		{"r", "RESTRICT"},
+		{"c", "CASCADE"},
+		{"n", "SET NULL"},
+		{"d", "SET DEFAULT"},
+	}
+	for _, d := range ff {
+		if actual := foreignKeyAction(d[0]); actual != d[1] {
+			t.Errorf("expected %s, actual: %s", d[1], actual)
+		}
+	}
+}
+
+func TestFindType(t *testing.T) {
+	ins := InspectResult{
+		Types: []Type{
+			{Schema: "billing", Name: "status"},
+			{Schema: "public", Name: "status"},
+			{Schema: "audit", Name: "status"},
+			{Schema: "billing", Name: "currency"},
+			{Schema: "billing", Name: "level"},
+			{Schema: "audit", Name: "level"},
+		},
+	}
+	ff := []struct {
+		name   string
+		schema string
+	}{
+		{"billing.status", "billing"},
+		{"audit.status", "audit"},
+		{"status", "public"},
+		{"currency", "billing"},
+		{"billing.level", "billing"},
+		{"level", ""},
+		{"unknown", ""},
+	}
+	for _, f := range ff {
+		typ, err := ins.FindType(f.name)
+		if f.schema == "" {
+			if err == nil {
+				t.Errorf("%s: should not be found, actual: %s.%s", f.name, typ.Schema, typ.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", f.name, err)
+			continue
+		}
+		if typ.Schema != f.schema {
+			t.Errorf("%s: expected %s, actual: %s", f.name, f.schema, typ.Schema)
+		}
+	}
+}
+
+func TestResolveDomain(t *testing.T) {
+	domains := []Domain{
+		{Schema: "public", Name: "email", BaseType: "character varying(320)", NotNull: true},
+		{Schema: "billing", Name: "amount", BaseType: "numeric(12,2)"},
+		{Schema: "billing", Name: "price", BaseType: "billing.amount"},
+	}
+	ff := []struct {
+		dataType string
+		expected string
+		notNull  bool
+	}{
+		{"email", "character varying(320)", true},
+		{"email[]", "character varying(320)[]", false},
+		{"billing.amount", "numeric(12,2)", false},
+		{"billing.price", "numeric(12,2)", false},
+		{"amount", "numeric(12,2)", false},
+		{"text", "text", false},
+		{"status", "status", false},
+	}
+	for _, f := range ff {
+		c := Column{DataType: f.dataType}
+		resolveDomain(&c, domains)
+		if c.DataType != f.expected || c.NotNull != f.notNull {
+			t.Errorf("%s: expected %s %t, actual: %s %t", f.dataType, f.expected, f.notNull, c.DataType, c.NotNull)
+		}
+	}
+}
+
+func TestTypeQueries(t *testing.T) {
+	// only enums are inspected as types, domains are resolved to the base type
+	if !strings.Contains(typesQuery, "t.typtype = 'e'") {
+		t.Errorf("types query must select only enums:\n%s", typesQuery)
+	}
+	if !strings.Contains(domainsQuery, "t.typtype = 'd'") {
+		t.Errorf("domains query must select only domains:\n%s", domainsQuery)
+	}
+}
@@ -34,7 +34,7 @@ func main() {
 		log.Fatal(fmt.Errorf("config file error: %s", err))
 	}
 
-	ins, err := Inspect(config.db)
+	ins, err := Inspect(config.db, config.Schemas)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PgType is a kind of Postgres built-in type. Generators map a PgType to a
+// type of the target language instead of matching type names by themselves.
+type PgType int
+
+const (
+	PgUnknown PgType = iota
+	PgBool
+	PgInt16
+	PgInt32
+	PgInt64
+	PgFloat32
+	PgFloat64
+	PgNumeric
+	PgMoney
+	PgText
+	PgUUID
+	PgBytes
+	PgDate
+	PgTime
+	PgTimeTZ
+	PgTimestamp
+	PgTimestampTZ
+	PgInterval
+	PgJSON
+	PgXML
+	PgNetwork    // inet, cidr
+	PgMacAddr    // macaddr, macaddr8
+	PgBit        // bit, bit varying
+	PgTextSearch // tsvector, tsquery
+	PgGeometric  // point, line, lseg, box, path, polygon, circle
+	PgRange      // range and multirange types
+)
+
+// pgTypes maps type names, as format_type() returns and their aliases, to
+// PgType. Names are normalized by normalizePgType.
+var pgTypes = map[string]PgType{
+	"boolean": PgBool,
+	"bool":    PgBool,
+
+	"smallint":    PgInt16,
+	"int2":        PgInt16,
+	"smallserial": PgInt16,
+	"serial2":     PgInt16,
+	"integer":     PgInt32,
+	"int":         PgInt32,
+	"int4":        PgInt32,
+	"serial":      PgInt32,
+	"serial4":     PgInt32,
+	"bigint":      PgInt64,
+	"int8":        PgInt64,
+	"bigserial":   PgInt64,
+	"serial8":     PgInt64,
+	"oid":         PgInt64,
+
+	"real":             PgFloat32,
+	"float4":           PgFloat32,
+	"double precision": PgFloat64,
+	"double":           PgFloat64,
+	"float":            PgFloat64,
+	"float8":           PgFloat64,
+	"numeric":          PgNumeric,
+	"decimal":          PgNumeric,
+	"money":            PgMoney,
+
+	"text":              PgText,
+	"character varying": PgText,
+	"varchar":           PgText,
+	"character":         PgText,
+	"char":              PgText,
+	"bpchar":            PgText,
+	"\"char\"":          PgText,
+	"name":              PgText,
+	"citext":            PgText,
+	"jsonpath":          PgText,
+	"pg_lsn":            PgText,
+	"uuid":              PgUUID,
+	"bytea":             PgBytes,
+
+	"date":                        PgDate,
+	"time":                        PgTime,
+	"time without time zone":      PgTime,
+	"time with time zone":         PgTimeTZ,
+	"timetz":                      PgTimeTZ,
+	"timestamp":                   PgTimestamp,
+	"timestamp without time zone": PgTimestamp,
+	"timestamp with time zone":    PgTimestampTZ,
+	"timestamptz":                 PgTimestampTZ,
+	"interval":                    PgInterval,
+
+	"json":  PgJSON,
+	"jsonb": PgJSON,
+	"xml":   PgXML,
+
+	"inet":        PgNetwork,
+	"cidr":        PgNetwork,
+	"macaddr":     PgMacAddr,
+	"macaddr8":    PgMacAddr,
+	"bit":         PgBit,
+	"bit varying": PgBit,
+	"varbit":      PgBit,
+	"tsvector":    PgTextSearch,
+	"tsquery":     PgTextSearch,
+
+	"point":   PgGeometric,
+	"line":    PgGeometric,
+	"lseg":    PgGeometric,
+	"box":     PgGeometric,
+	"path":    PgGeometric,
+	"polygon": PgGeometric,
+	"circle":  PgGeometric,
+
+	"int4range":      PgRange,
+	"int8range":      PgRange,
+	"numrange":       PgRange,
+	"tsrange":        PgRange,
+	"tstzrange":      PgRange,
+	"daterange":      PgRange,
+	"int4multirange": PgRange,
+	"int8multirange": PgRange,
+	"nummultirange":  PgRange,
+	"tsmultirange":   PgRange,
+	"tstzmultirange": PgRange,
+	"datemultirange": PgRange,
+}
+
+// regTypeModifier matches type modifiers such as "(255)" or "(10,2)".
+var regTypeModifier = regexp.MustCompile(`\([^)]*\)`)
+
+// normalizePgType removes array brackets and type modifiers from t, so
+// "timestamp(3) with time zone[]" becomes "timestamp with time zone".
+// Fields of interval such as "interval year to month" are removed too.
+func normalizePgType(t string) string {
+	t = strings.TrimSpace(t)
+	for strings.HasSuffix(t, "[]") {
+		t = strings.TrimSuffix(t, "[]")
+	}
+	t = regTypeModifier.ReplaceAllString(t, "")
+	t = strings.Join(strings.Fields(t), " ")
+	if strings.HasPrefix(t, "interval ") {
+		t = "interval"
+	}
+	return t
+}
+
+// regTypeArgs matches the arguments of a type modifier.
+var regTypeArgs = regexp.MustCompile(`\((\d+)(?:,\s*(\d+))?\)`)
+
+// parseDataType fills the fields of c parsed from DataType, the output of
+// format_type(). format_type() always writes a single "[]", so ArrayDims
+// which comes from attndims is kept if it is larger.
+func parseDataType(c *Column) {
+	c.ElementType = c.DataType
+	dims := 0
+	for strings.HasSuffix(c.ElementType, "[]") {
+		c.ElementType = strings.TrimSuffix(c.ElementType, "[]")
+		dims++
+	}
+	if dims > c.ArrayDims {
+		c.ArrayDims = dims
+	}
+	c.BaseType = normalizePgType(c.ElementType)
+
+	m := regTypeArgs.FindStringSubmatch(c.ElementType)
+	if m == nil {
+		return
+	}
+	n, _ := strconv.Atoi(m[1])
+	pt, _ := lookupPgType(c.BaseType)
+	switch pt {
+	case PgText, PgBit:
+		c.Length = n
+	case PgNumeric:
+		c.Precision = n
+		if m[2] != "" {
+			c.Scale, _ = strconv.Atoi(m[2])
+		}
+	case PgTime, PgTimeTZ, PgTimestamp, PgTimestampTZ, PgInterval:
+		c.TimePrecision = sql.NullInt64{Int64: int64(n), Valid: true}
+	}
+}
+
+// lookupPgType returns the PgType of t, a type name as format_type()
+// returns. ok is false for enums and other user defined types.
+func lookupPgType(t string) (PgType, bool) {
+	ret, ok := pgTypes[normalizePgType(t)]
+	return ret, ok
+}
+
+// warnUnknownType logs that t is not known by a generator and fallback is
+// used instead.
+func warnUnknownType(generator, t, fallback string) {
+	log.Printf("WARN: %s: unknown type %s, use %s", generator, t, fallback)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizePgType(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected string
+	}{
+		{"text", "text"},
+		{"character varying(255)", "character varying"},
+		{"numeric(10,2)", "numeric"},
+		{"timestamp(3) with time zone", "timestamp with time zone"},
+		{"time(6) without time zone[]", "time without time zone"},
+		{"integer[][]", "integer"},
+		{"bit varying(8)", "bit varying"},
+		{"interval year to month", "interval"},
+		{"interval(6)", "interval"},
+	}
+	for _, c := range cases {
+		if actual := normalizePgType(c.in); actual != c.expected {
+			t.Errorf("expected %s, actual: %s", c.expected, actual)
+		}
+	}
+}
+
+func TestLookupPgType(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected PgType
+	}{
+		{"smallint", PgInt16},
+		{"real", PgFloat32},
+		{"money", PgMoney},
+		{"time without time zone", PgTime},
+		{"time with time zone", PgTimeTZ},
+		{"timestamp without time zone", PgTimestamp},
+		{"timestamp(6) with time zone", PgTimestampTZ},
+		{"interval day to second", PgInterval},
+		{"inet", PgNetwork},
+		{"cidr", PgNetwork},
+		{"macaddr", PgMacAddr},
+		{"xml", PgXML},
+		{"bit varying(8)", PgBit},
+		{"tsvector", PgTextSearch},
+		{"point", PgGeometric},
+		{"tstzrange", PgRange},
+		{"character(10)", PgText},
+		{"jsonb[]", PgJSON},
+	}
+	for _, c := range cases {
+		actual, ok := lookupPgType(c.in)
+		if !ok || actual != c.expected {
+			t.Errorf("%s: expected %d, actual: %d", c.in, c.expected, actual)
+		}
+	}
+	if _, ok := lookupPgType("order_status"); ok {
+		t.Error("user defined type should not be found")
+	}
+}
+
+// TestPgTypeCoverage checks that every PgType has a counterpart in the
+// generators which map with a table.
+func TestPgTypeCoverage(t *testing.T) {
+	for pt := PgBool; pt <= PgRange; pt++ {
+		if hibernateTypes[pt] == "" {
+			t.Errorf("hibernate has no type of %d", pt)
+		}
+		if goTypes[pt] == "" {
+			t.Errorf("go has no type of %d", pt)
+		}
+	}
+	gen := newTestProtoBuf(ProtoBufConfig{})
+	scalars := []string{"double", "float", "int32", "int64", "bool", "string", "bytes", "map<string, string>"}
+	for name := range pgTypes {
+		typ := gen.convertType(Column{DataType: name})
+		if !contains(scalars, typ) && !strings.HasPrefix(typ, "google.") {
+			t.Errorf("protobuf has no type of %s: %s", name, typ)
+		}
+	}
+}
+
+func TestParseDataType(t *testing.T) {
+	cases := []struct {
+		dataType string
+		dims     int // attndims
+		expected Column
+	}{
+		{"character varying(255)", 0, Column{BaseType: "character varying", ElementType: "character varying(255)", Length: 255}},
+		{"numeric(12,4)", 0, Column{BaseType: "numeric", ElementType: "numeric(12,4)", Precision: 12, Scale: 4}},
+		{"numeric(10)", 0, Column{BaseType: "numeric", ElementType: "numeric(10)", Precision: 10}},
+		{"numeric", 0, Column{BaseType: "numeric", ElementType: "numeric"}},
+		{"timestamp(3) with time zone", 0, Column{BaseType: "timestamp with time zone", ElementType: "timestamp(3) with time zone", TimePrecision: sql.NullInt64{Int64: 3, Valid: true}}},
+		{"time(0) without time zone", 0, Column{BaseType: "time without time zone", ElementType: "time(0) without time zone", TimePrecision: sql.NullInt64{Int64: 0, Valid: true}}},
+		{"character(10)[]", 1, Column{BaseType: "character", ElementType: "character(10)", ArrayDims: 1, Length: 10}},
+		{"integer[]", 2, Column{BaseType: "integer", ElementType: "integer", ArrayDims: 2}},
+		{"bit varying(8)", 0, Column{BaseType: "bit varying", ElementType: "bit varying(8)", Length: 8}},
+		{"order_status", 0, Column{BaseType: "order_status", ElementType: "order_status"}},
+	}
+	for _, c := range cases {
+		col := Column{DataType: c.dataType, ArrayDims: c.dims}
+		parseDataType(&col)
+		c.expected.DataType = c.dataType
+		if !reflect.DeepEqual(col, c.expected) {
+			t.Errorf("expected %+v, actual: %+v", c.expected, col)
+		}
+	}
+}
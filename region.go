@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hand written code is kept between a "pg2any:begin NAME" line and a
+// "pg2any:end NAME" line of a generated file. The comment syntax of the
+// line does not matter.
+var (
+	regRegionBegin = regexp.MustCompile(`pg2any:begin\s+(\S+)`)
+	regRegionEnd   = regexp.MustCompile(`pg2any:end\s+(\S+)`)
+)
+
+// writeFile writes src to name under dir, making the schema sub directory if
+// needed. If the file already exists, the contents of its regions are
+// carried over to the same regions of src. The file is skipped with a warning
+// if a region which has contents would be lost, unless name matches one of
+// overwrites (regexp), which allows the file to be replaced entirely. It
+// reports whether the file is written.
+func writeFile(dir, name string, src []byte, overwrites []string) (bool, error) {
+	path := filepath.Join(dir, name)
+	if !partContainsRegex(overwrites, filepath.ToSlash(name)) {
+		existing, err := ioutil.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return false, err
+		default:
+			merged, err := mergeRegions(src, existing)
+			if err != nil {
+				log.Printf("WARN: skip %s: %s", path, err)
+				return false, nil
+			}
+			src = merged
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	return true, ioutil.WriteFile(path, src, 0644)
+}
+
+// regionWriter writes the files of a generator by writeFile. Skipped files
+// are kept, so that Build writes the other files and then fails.
+type regionWriter struct {
+	dir        string
+	overwrites []string
+	skipped    []string
+}
+
+func (w *regionWriter) write(name string, src []byte) (bool, error) {
+	written, err := writeFile(w.dir, name, src, w.overwrites)
+	if err == nil && !written {
+		w.skipped = append(w.skipped, name)
+	}
+	return written, err
+}
+
+// err returns an error which lists the skipped files, if any.
+func (w *regionWriter) err() error {
+	if len(w.skipped) == 0 {
+		return nil
+	}
+	return fmt.Errorf("regions would be lost, not written: %s", strings.Join(w.skipped, ", "))
+}
+
+// mergeRegions replaces the contents of the regions of generated by the ones
+// of existing. It fails if existing has a region with contents which is not
+// in generated.
+func mergeRegions(generated, existing []byte) ([]byte, error) {
+	if _, err := parseRegions(generated); err != nil {
+		return nil, fmt.Errorf("generated file: %s", err)
+	}
+	regions, err := parseRegions(existing)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	var name string
+	merged := make(map[string]bool)
+	for _, line := range strings.SplitAfter(string(generated), "\n") {
+		if name != "" {
+			if !regRegionEnd.MatchString(line) {
+				// default contents of the template
+				continue
+			}
+			name = ""
+		} else if m := regRegionBegin.FindStringSubmatch(line); m != nil {
+			if contents, ok := regions[m[1]]; ok {
+				buf.WriteString(line)
+				buf.WriteString(contents)
+				merged[m[1]] = true
+				name = m[1]
+				continue
+			}
+		}
+		buf.WriteString(line)
+	}
+
+	var names []string
+	for name := range regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !merged[name] && strings.TrimSpace(regions[name]) != "" {
+			return nil, fmt.Errorf("region %s would be lost", name)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// parseRegions returns the contents of the regions of src keyed by name.
+func parseRegions(src []byte) (map[string]string, error) {
+	ret := make(map[string]string)
+	var name string
+	var contents []string
+	for _, line := range strings.SplitAfter(string(src), "\n") {
+		if m := regRegionBegin.FindStringSubmatch(line); m != nil {
+			if name != "" {
+				return nil, fmt.Errorf("region %s is not closed", name)
+			}
+			if _, ok := ret[m[1]]; ok {
+				return nil, fmt.Errorf("region %s is duplicated", m[1])
+			}
+			name, contents = m[1], nil
+			continue
+		}
+		if m := regRegionEnd.FindStringSubmatch(line); m != nil {
+			if m[1] != name {
+				return nil, fmt.Errorf("unexpected end of region %s", m[1])
+			}
+			ret[name] = strings.Join(contents, "")
+			name = ""
+			continue
+		}
+		if name != "" {
+			contents = append(contents, line)
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("region %s is not closed", name)
+	}
+	return ret, nil
+}
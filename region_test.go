@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeRegions(t *testing.T) {
+	generated := "class A {\n    int b;\n    // pg2any:begin custom\n    // default\n    // pg2any:end custom\n}\n// pg2any:begin extra\n// pg2any:end extra\n"
+	cases := []struct {
+		existing string
+		expected string
+		err      bool
+	}{
+		{
+			"class A {\n    int a;\n    // pg2any:begin custom\n    void foo() {}\n    // pg2any:end custom\n}\n",
+			"class A {\n    int b;\n    // pg2any:begin custom\n    void foo() {}\n    // pg2any:end custom\n}\n// pg2any:begin extra\n// pg2any:end extra\n",
+			false,
+		},
+		{"class A {\n    int a;\n}\n", generated, false},
+		{"// pg2any:begin gone\n\n// pg2any:end gone\n", generated, false},
+		{"// pg2any:begin gone\nvoid foo() {}\n// pg2any:end gone\n", "", true},
+		{"// pg2any:begin custom\nvoid foo() {}\n", "", true},
+		{"// pg2any:end custom\n", "", true},
+	}
+	for _, c := range cases {
+		actual, err := mergeRegions([]byte(generated), []byte(c.existing))
+		if c.err {
+			if err == nil {
+				t.Errorf("%q: error expected", c.existing)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", c.existing, err)
+			continue
+		}
+		if string(actual) != c.expected {
+			t.Errorf("expected %q, actual: %q", c.expected, actual)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg2any")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join("billing", "Foo.java")
+	path := filepath.Join(dir, name)
+	read := func() string {
+		buf, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+
+	if _, err := writeFile(dir, name, []byte("a\n// pg2any:begin custom\n// pg2any:end custom\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+	edited := "a\n// pg2any:begin custom\nfoo\n// pg2any:end custom\n"
+	if err := ioutil.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := writeFile(dir, name, []byte("b\n// pg2any:begin custom\n// pg2any:end custom\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "b\n// pg2any:begin custom\nfoo\n// pg2any:end custom\n"; read() != expected {
+		t.Errorf("expected %q, actual: %q", expected, read())
+	}
+
+	// the region would be lost, the file is kept
+	if _, err := writeFile(dir, name, []byte("c\n"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "b\n// pg2any:begin custom\nfoo\n// pg2any:end custom\n"; read() != expected {
+		t.Errorf("expected %q, actual: %q", expected, read())
+	}
+
+	// allowed to be replaced
+	if _, err := writeFile(dir, name, []byte("c\n"), []string{`^billing/Foo\.java$`}); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "c\n"; read() != expected {
+		t.Errorf("expected %q, actual: %q", expected, read())
+	}
+}
+
+func TestRegionWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pg2any")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	edited := "a\n// pg2any:begin custom\nfoo\n// pg2any:end custom\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "Foo.java"), []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := &regionWriter{dir: dir}
+	if written, err := w.write("Foo.java", []byte("b\n")); err != nil || written {
+		t.Errorf("should be skipped: %t %v", written, err)
+	}
+	if written, err := w.write("Bar.java", []byte("b\n")); err != nil || !written {
+		t.Errorf("should be written: %t %v", written, err)
+	}
+	if err := w.err(); err == nil || !strings.Contains(err.Error(), "Foo.java") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}